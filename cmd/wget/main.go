@@ -4,10 +4,34 @@ import (
 	"log"
 	"os"
 	"wget-go/internal/app"
+	"wget-go/internal/config"
 )
 
 func main() {
-	application := app.New()
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		if err := app.RunCacheCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Cache command failed: %s\n", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "robots-check" {
+		if err := app.RunRobotsCheckCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Robots check failed: %s\n", err)
+		}
+		return
+	}
+
+	cfg := config.MustLoad()
+
+	// С -test-url правила только объясняются, обход не запускается
+	if len(cfg.TestURLs) > 0 {
+		if err := app.RunTestURLs(cfg); err != nil {
+			log.Fatalf("URL test failed: %s\n", err)
+		}
+		return
+	}
+
+	application := app.New(cfg)
 	if err := application.Run(); err != nil {
 		log.Fatalf(
 			"Application failed: %s\n",
@@ -2,7 +2,10 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,81 +13,377 @@ import (
 	httpserver "wget-go/internal/delivery/http-server"
 
 	"wget-go/internal/delivery/http-server/client"
+	"wget-go/internal/delivery/http-server/dialer"
+	"wget-go/internal/delivery/http-server/har"
+	"wget-go/internal/delivery/http-server/httpcache"
 	"wget-go/internal/delivery/http-server/ratelimiter"
 	"wget-go/internal/delivery/http-server/robots"
+	"wget-go/internal/domain"
+	"wget-go/internal/service"
+	"wget-go/internal/service/checksum"
 	"wget-go/internal/service/downloader"
 	"wget-go/internal/service/extractor"
+	"wget-go/internal/service/frontier"
 	"wget-go/internal/service/html_parser"
+	"wget-go/internal/service/metalink"
+	"wget-go/internal/service/priority"
+	"wget-go/internal/service/quota"
 	"wget-go/internal/service/scheduler"
+	"wget-go/internal/service/scope"
+	"wget-go/internal/service/segmented"
+	"wget-go/internal/service/sitemap"
+	"wget-go/internal/service/urlfilter"
+	"wget-go/internal/storage"
+	"wget-go/internal/storage/checkpoint"
 	"wget-go/internal/storage/file_manager"
 	"wget-go/internal/storage/link_rewriter"
 	"wget-go/internal/storage/path_resolver"
+	"wget-go/internal/storage/skip_log"
 )
 
 // Application основное приложение
 type Application struct {
-	config    *config.Config
-	scheduler *scheduler.DownloadScheduler
+	config     *config.Config
+	scheduler  *scheduler.DownloadScheduler
+	downloader *downloader.WebDownloader
+	limiters   *ratelimiter.HostRegistry
+	recorder   *har.Recorder
+	replay     *client.ReplayClient
+	sitemaps   *sitemap.Discoverer
+	skipLog    *skip_log.SkipLogImpl
 }
 
-// New создает и инициализирует приложение
-func New() *Application {
-	cfg := config.MustLoad()
+// New создает и инициализирует приложение по загруженной конфигурации
+func New(cfg *config.Config) *Application {
+	netDialer := newDialer(cfg)
+	quotaTracker := quota.New(cfg)
+	rateLimiter := ratelimiter.NewRegistry(cfg)
 
-	rateLimiter := ratelimiter.New(cfg.RateLimit)
+	var recorder *har.Recorder
+	if cfg.HAR != "" {
+		recorder = har.NewRecorder(cfg.HAR, int64(cfg.HARBodySize), cfg.HARRedact)
+	}
+	wrappers := transportWrappers(cfg, recorder, quotaTracker)
 
-	// Создаем robots checker если включено
-	var robotsChecker httpserver.RobotsChecker
-	if cfg.RespectRobots {
-		// Создаем временный клиент для загрузки robots.txt
-		tempClient := client.New(cfg, rateLimiter, nil)
-		robotsChecker = robots.New(tempClient)
-		robotsChecker.SetUserAgent(cfg.UserAgent)
+	// Адаптивный контроллер скорости если включено
+	var rateController httpserver.RateController
+	if cfg.Adaptive {
+		rateController = ratelimiter.NewAdaptive(rateLimiter, cfg.MinRate, cfg.MaxRate)
+	}
+
+	var webClient httpserver.Client
+	var replayClient *client.ReplayClient
+	var robotsImpl *robots.RobotsCheckerImpl
+	if cfg.Replay != "" {
+		replayClient = newReplayClient(cfg, quotaTracker)
+		webClient = replayClient
+	} else {
+		// Создаем robots checker если включено
+		var robotsChecker httpserver.RobotsChecker
+		if cfg.RespectRobots {
+			robotsImpl = robots.New(nil, rateLimiter, cfg.MaxCrawlDelay)
+			robotsImpl.SetUserAgent(cfg.UserAgent)
+			robotsChecker = robotsImpl
+		}
+
+		liveClient := client.New(
+			cfg,
+			netDialer,
+			rateLimiter,
+			rateController,
+			quotaTracker,
+			robotsChecker,
+			wrappers...,
+		)
+		// robots.txt загружается тем же клиентом, что и остальные ресурсы
+		if robotsImpl != nil {
+			robotsImpl.SetClient(liveClient)
+		}
+		webClient = liveClient
+	}
+
+	// При воспроизведении ftp:// тоже отвечает архив, а не сеть
+	var ftpClient httpserver.Client
+	if replayClient != nil {
+		ftpClient = replayClient
+	} else {
+		ftpClient = client.NewFTP(cfg, netDialer, rateLimiter, quotaTracker)
+	}
+	httpClient := newSchemeClient(webClient, ftpClient)
+
+	// Поиск sitemap если включено. Адреса sitemap берутся из того же
+	// robots.txt, что проверяет правила, иначе robots.txt читается отдельно
+	var sitemapDiscoverer *sitemap.Discoverer
+	if cfg.Sitemaps || cfg.SitemapsOnly {
+		if robotsImpl == nil {
+			robotsImpl = robots.New(webClient, nil, 0)
+			robotsImpl.SetUserAgent(cfg.UserAgent)
+		}
+		sitemapDiscoverer = sitemap.New(cfg, httpClient, robotsImpl)
 	}
 
-	httpClient := client.New(cfg, rateLimiter, robotsChecker)
 	fileManager := file_manager.New()
 	pathResolver := path_resolver.New(cfg.OutputDir)
 	linkRewriter := link_rewriter.New(pathResolver)
 
+	skipLog, err := skip_log.New(cfg.SkipLog)
+	if err != nil {
+		log.Fatalf("Failed to open skip log: %v", err)
+	}
+
+	// Деление больших файлов на диапазоны если включено
+	var segmentedFetcher service.SegmentedFetcher
+	if cfg.Segments > 1 {
+		segmentedFetcher = segmented.New(cfg, httpClient)
+	}
+
 	htmlParser := html_parser.New()
 	linkExtractor := extractor.New(htmlParser)
 
 	webDownloader := downloader.New(
+		cfg,
 		httpClient,
 		fileManager,
 		pathResolver,
 		linkRewriter,
 		linkExtractor,
+		quotaTracker,
+		segmentedFetcher,
+		checksum.New(cfg, httpClient),
+		skipLog,
 	)
 
+	// Состояние обхода если задан -resume. Сохраненное с другими
+	// настройками состояние не продолжается
+	var crawlCheckpoint storage.Checkpoint
+	var savedState *domain.CrawlState
+	if cfg.Resume != "" {
+		stateStore := checkpoint.New(cfg.Resume, cfg)
+		savedState, err = stateStore.Load()
+		if err != nil {
+			log.Fatalf("Cannot resume: %v", err)
+		}
+		crawlCheckpoint = stateStore
+	}
+
+	// Оценка задач нужна только для обхода best-first
+	var scorer service.Scorer
+	if cfg.CrawlOrder == frontier.OrderBestFirst {
+		scorer = priority.New(cfg)
+	}
+
 	downloadScheduler := scheduler.New(
 		cfg,
 		webDownloader,
 		pathResolver,
+		rateLimiter,
+		quotaTracker,
+		frontier.New(cfg),
+		crawlCheckpoint,
+		scorer,
+		scope.New(cfg),
+		urlfilter.New(cfg),
+		skipLog,
 	)
 
+	if savedState != nil {
+		downloadScheduler.Restore(savedState)
+	}
+
+	if cfg.Metalink != "" {
+		downloadScheduler.AddSeeds(metalinkTasks(cfg)...)
+	}
+
 	return &Application{
-		config:    cfg,
-		scheduler: downloadScheduler,
+		config:     cfg,
+		scheduler:  downloadScheduler,
+		downloader: webDownloader,
+		limiters:   rateLimiter,
+		recorder:   recorder,
+		replay:     replayClient,
+		sitemaps:   sitemapDiscoverer,
+		skipLog:    skipLog,
+	}
+}
+
+// newDialer собирает диалер с учетом -resolve, DNS кэша и семейства адресов
+func newDialer(cfg *config.Config) *dialer.Dialer {
+	overrides, err := dialer.ParseOverrides(cfg.Resolve)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	var resolver dialer.Resolver = net.DefaultResolver
+	if cfg.DNSCacheTTL > 0 {
+		resolver = dialer.NewCaching(resolver, cfg.DNSCacheTTL)
+	}
+
+	family := dialer.FamilyAny
+	switch {
+	case cfg.IPv4Only:
+		family = dialer.FamilyIPv4
+	case cfg.IPv6Only:
+		family = dialer.FamilyIPv6
+	}
+
+	var guard *dialer.Guard
+	if cfg.BlockPrivate {
+		guard, err = dialer.NewGuard(cfg.AllowCIDRs)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+	}
+
+	return dialer.New(resolver, overrides, family, guard, cfg.Timeout)
+}
+
+// metalinkTasks превращает файлы из -metalink в задачи без рекурсии
+func metalinkTasks(cfg *config.Config) []domain.DownloadTask {
+	files, err := metalink.Load(cfg.Metalink)
+	if err != nil {
+		log.Fatalf("Failed to load metalink: %v", err)
+	}
+
+	tasks := make([]domain.DownloadTask, 0, len(files))
+	for _, file := range files {
+		log.Printf("Metalink file %s: %d mirrors, %d checksums", file.Name, len(file.URLs), len(file.Checksums))
+		tasks = append(tasks, file.Task(cfg.MaxDepth))
+	}
+	return tasks
+}
+
+// newSchemeClient собирает диспетчер схем. file:// и data:// читаются
+// локально, остальные схемы обслуживают переданные клиенты
+func newSchemeClient(webClient, ftpClient httpserver.Client) *client.SchemeClient {
+	schemeClient := client.NewSchemeClient()
+	schemeClient.Register(webClient, "http", "https")
+	schemeClient.Register(ftpClient, "ftp")
+	schemeClient.Register(client.NewFileClient(), "file")
+	schemeClient.Register(client.NewDataClient(), "data")
+	return schemeClient
+}
+
+// newReplayClient загружает архив -replay и создает клиент воспроизведения
+func newReplayClient(cfg *config.Config, bodyLimiter httpserver.BodyLimiter) *client.ReplayClient {
+	archive, err := client.LoadArchive(cfg.Replay)
+	if err != nil {
+		log.Fatalf("Failed to load replay archive: %v", err)
+	}
+	log.Printf("Loaded %d recorded responses from %s", archive.Len(), cfg.Replay)
+
+	// robots.txt тоже берется из архива; отсутствующий файл разрешает все
+	var robotsChecker httpserver.RobotsChecker
+	if cfg.RespectRobots {
+		robotsChecker = robots.New(client.NewReplay(archive, false, nil, nil), nil, 0)
+		robotsChecker.SetUserAgent(cfg.UserAgent)
+	}
+
+	return client.NewReplay(archive, cfg.ReplayStrict, bodyLimiter, robotsChecker)
+}
+
+// transportWrappers собирает дополнительные слои HTTP транспорта
+func transportWrappers(cfg *config.Config, recorder *har.Recorder, bodyLimiter httpserver.BodyLimiter) []httpserver.TransportWrapper {
+	var wrappers []httpserver.TransportWrapper
+
+	if cfg.Cache {
+		store, err := httpcache.Open(cfg.CacheDir, int64(cfg.CacheSize))
+		if err != nil {
+			log.Fatalf("Failed to open HTTP cache: %v", err)
+		}
+		wrappers = append(wrappers, func(next http.RoundTripper) http.RoundTripper {
+			return httpcache.NewTransport(next, store, cfg.Offline, bodyLimiter)
+		})
+	}
+
+	// HAR записывает обмены в том виде, в каком их видит клиент,
+	// включая ответы из кэша и каждый шаг редиректа
+	if recorder != nil {
+		wrappers = append(wrappers, recorder.Wrap)
 	}
+
+	return wrappers
 }
 
 // Run запускает приложение
 func (a *Application) Run() error {
 	log.Printf("Wget-Go starting...")
-	log.Printf("URL: %s", a.config.URL)
+	if a.config.URL != "" {
+		log.Printf("URL: %s", a.config.URL)
+	}
 	log.Printf("Output directory: %s", a.config.OutputDir)
 	log.Printf("Max depth: %d, Workers: %d, Rate limit: %d/sec",
 		a.config.MaxDepth, a.config.Workers, a.config.RateLimit)
 	log.Printf("Respect robots.txt: %v", a.config.RespectRobots)
+	if a.config.Wait > 0 {
+		log.Printf("Wait between requests: %s (random: %v)", a.config.Wait, a.config.RandomWait)
+	}
+	if a.config.Cache {
+		log.Printf("HTTP cache: %s (offline: %v)", a.config.CacheDir, a.config.Offline)
+	}
+	if a.config.HAR != "" {
+		log.Printf("Recording HTTP traffic to %s (redact: %v)", a.config.HAR, a.config.HARRedact)
+	}
+	if a.config.Replay != "" {
+		log.Printf("Replaying from %s (strict: %v)", a.config.Replay, a.config.ReplayStrict)
+	}
+	if a.config.Metalink != "" {
+		log.Printf("Metalink: %s", a.config.Metalink)
+	}
+	if a.config.Sitemaps || a.config.SitemapsOnly {
+		log.Printf("Sitemaps: enabled (only: %v, order: %q)", a.config.SitemapsOnly, a.config.SitemapOrder)
+	}
+	if a.config.SkipLog != "" {
+		log.Printf("Skip log: %s", a.config.SkipLog)
+	}
+	log.Printf("Crawl order: %s", a.config.CrawlOrder)
+	if a.config.Resume != "" {
+		log.Printf("Crawl state: %s (saved every %s)", a.config.Resume, a.config.CheckpointInterval)
+	}
+	if a.config.Verify {
+		log.Printf("Verifying checksums from Digest headers and sidecar files")
+	}
+	if a.config.BlockPrivate {
+		log.Printf("Blocking private destinations (allowed: %v)", a.config.AllowCIDRs)
+	}
+	if a.config.Quota > 0 {
+		log.Printf("Download quota: %d bytes", a.config.Quota)
+	}
+	for _, entry := range a.config.Resolve {
+		log.Printf("Resolve override: %s", entry)
+	}
+	if a.config.Adaptive {
+		log.Printf("Adaptive rate: min %d/sec, max %d/sec", a.config.MinRate, a.config.MaxRate)
+	}
+	for host, hostCfg := range a.config.Hosts {
+		log.Printf("Host override %s: rate limit %d/sec, wait %s", host, hostCfg.RateLimit, hostCfg.Wait.Duration)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	defer a.limiters.Close()
+	defer a.closeRecorder()
+	defer a.closeSkipLog()
 
 	go a.handleSignals(cancel)
 
-	if err := a.scheduler.Start(ctx); err != nil {
+	if a.sitemaps != nil {
+		a.scheduler.AddSeeds(a.sitemaps.Discover(ctx, a.config.URL)...)
+	}
+
+	err := a.scheduler.Start(ctx)
+
+	if fixErr := a.downloader.FixRenamedLinks(); fixErr != nil {
+		log.Printf("Failed to fix renamed links: %v", fixErr)
+	}
+
+	if err != nil {
+		if a.config.Resume != "" {
+			log.Printf("Crawl state saved, continue with -resume %s", a.config.Resume)
+		}
+		return err
+	}
+
+	if err := a.checkReplayMisses(); err != nil {
 		return err
 	}
 
@@ -92,6 +391,42 @@ func (a *Application) Run() error {
 	return nil
 }
 
+// checkReplayMisses сообщает об URL, которых не было в архиве -replay.
+// В строгом режиме такие URL делают запуск неуспешным
+func (a *Application) checkReplayMisses() error {
+	if a.replay == nil {
+		return nil
+	}
+
+	misses := a.replay.Misses()
+	for _, rawURL := range misses {
+		log.Printf("Not in replay archive: %s", rawURL)
+	}
+	if a.config.ReplayStrict && len(misses) > 0 {
+		return fmt.Errorf("%d requested URLs are missing from replay archive", len(misses))
+	}
+	return nil
+}
+
+// closeRecorder сохраняет HAR файл, если запись включена
+func (a *Application) closeRecorder() {
+	if a.recorder == nil {
+		return
+	}
+	if err := a.recorder.Close(); err != nil {
+		log.Printf("Failed to write HAR file: %v", err)
+		return
+	}
+	log.Printf("HAR written to %s", a.config.HAR)
+}
+
+// closeSkipLog дописывает журнал пропусков на диск
+func (a *Application) closeSkipLog() {
+	if err := a.skipLog.Close(); err != nil {
+		log.Printf("Failed to write skip log: %v", err)
+	}
+}
+
 // handleSignals обрабатывает сигналы OS для graceful shutdown
 func (a *Application) handleSignals(cancel context.CancelFunc) {
 	sigChan := make(chan os.Signal, 1)
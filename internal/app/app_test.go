@@ -0,0 +1,45 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"wget-go/internal/config"
+	"wget-go/internal/delivery/http-server/client"
+)
+
+const testHAR = `{"log": {"version": "1.2", "entries": [{
+	"request": {"method": "GET", "url": "https://example.com/"},
+	"response": {"status": 200, "headers": [{"name": "Content-Type", "value": "text/html"}],
+		"content": {"text": "<a href=\"ftp://files.example.com/pub/data.tar\">data</a>"}}
+}]}}`
+
+func TestReplayServesEverySchemeFromArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "golden.har")
+	if err := os.WriteFile(archive, []byte(testHAR), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Replay = archive
+	cfg.ReplayStrict = true
+
+	replay := newReplayClient(cfg, nil)
+	schemeClient := newSchemeClient(replay, replay)
+	ctx := context.Background()
+
+	if resp, err := schemeClient.Get(ctx, "https://example.com/"); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("recorded page: %v, %v", resp, err)
+	}
+
+	// ftp:// не уходит в сеть, а отвечается из архива, где его нет
+	const ftpURL = "ftp://files.example.com/pub/data.tar"
+	if _, err := schemeClient.Get(ctx, ftpURL); !errors.Is(err, client.ErrNotRecorded) {
+		t.Fatalf("ftp:// under -replay: err = %v, want ErrNotRecorded", err)
+	}
+	if misses := replay.Misses(); !reflect.DeepEqual(misses, []string{ftpURL}) {
+		t.Fatalf("misses = %v, want %v", misses, []string{ftpURL})
+	}
+}
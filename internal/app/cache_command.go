@@ -0,0 +1,108 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+	"wget-go/internal/config"
+	"wget-go/internal/delivery/http-server/httpcache"
+)
+
+// RunCacheCommand выполняет подкоманду cache: просмотр или очистку HTTP кэша
+func RunCacheCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: wget-go cache list|purge [-cache-dir DIR] [-stale] [URL...]")
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("cache "+action, flag.ExitOnError)
+	cacheDir := flags.String("cache-dir", config.DefaultCacheDir, "HTTP cache directory")
+	staleOnly := flags.Bool("stale", false, "Only entries that are no longer fresh")
+	flags.Parse(args[1:])
+
+	store, err := httpcache.Open(*cacheDir, 0)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		return listCache(store, *staleOnly)
+	case "purge":
+		return purgeCache(store, *staleOnly, flags.Args())
+	default:
+		return fmt.Errorf("unknown cache command %q, expected list or purge", action)
+	}
+}
+
+// listCache выводит записи кэша
+func listCache(store *httpcache.Store, staleOnly bool) error {
+	entries, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tSIZE\tAGE\tSTATE\tURL")
+
+	for _, entry := range entries {
+		fresh := entry.IsFresh(now)
+		if staleOnly && fresh {
+			continue
+		}
+
+		state := "stale"
+		if fresh {
+			state = "fresh"
+		}
+		fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t%s\n",
+			entry.StatusCode, entry.Size, entry.Age(now).Round(time.Second), state, entry.URL)
+	}
+
+	fmt.Fprintf(writer, "\n%d entries, %d bytes\n", len(entries), store.Size())
+	return writer.Flush()
+}
+
+// purgeCache удаляет указанные URL, устаревшие записи или весь кэш
+func purgeCache(store *httpcache.Store, staleOnly bool, urls []string) error {
+	switch {
+	case len(urls) > 0:
+		for _, rawURL := range urls {
+			if err := store.Delete(rawURL); err != nil {
+				return err
+			}
+			fmt.Printf("Purged %s\n", rawURL)
+		}
+		return nil
+
+	case staleOnly:
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		purged := 0
+		for _, entry := range entries {
+			if entry.IsFresh(now) {
+				continue
+			}
+			if err := store.Delete(entry.URL); err != nil {
+				return err
+			}
+			purged++
+		}
+		fmt.Printf("Purged %d stale entries\n", purged)
+		return nil
+
+	default:
+		if err := store.Purge(); err != nil {
+			return err
+		}
+		fmt.Println("Cache purged")
+		return nil
+	}
+}
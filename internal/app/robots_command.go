@@ -0,0 +1,134 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"wget-go/internal/config"
+	"wget-go/internal/delivery/http-server/client"
+	"wget-go/internal/delivery/http-server/ratelimiter"
+	"wget-go/internal/delivery/http-server/robots"
+)
+
+// RunRobotsCheckCommand выполняет подкоманду robots-check: объясняет,
+// разрешен ли URL по robots.txt и каким правилом
+func RunRobotsCheckCommand(args []string) error {
+	cfg := config.Default()
+
+	flags := flag.NewFlagSet("robots-check", flag.ExitOnError)
+	flags.StringVar(&cfg.UserAgent, "user-agent", cfg.UserAgent, "User-Agent to check rules for")
+	flags.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "Request timeout")
+	flags.DurationVar(&cfg.MaxCrawlDelay, "max-crawl-delay", cfg.MaxCrawlDelay, "Cap for robots.txt Crawl-delay (0 ignores Crawl-delay)")
+	localFile := flags.String("file", "", "Read robots.txt from a local file instead of the site")
+
+	// URL может стоять как до флагов, так и после них
+	var rawURL string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		rawURL, args = args[0], args[1:]
+	}
+	flags.Parse(args)
+	if rawURL == "" && flags.NArg() > 0 {
+		rawURL = flags.Arg(0)
+	}
+	if rawURL == "" {
+		return fmt.Errorf("usage: wget-go robots-check <url> [-user-agent UA] [-file robots.txt]")
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	var robotsTxt *robots.RobotsTxt
+	var source string
+	if *localFile != "" {
+		content, err := os.ReadFile(*localFile)
+		if err != nil {
+			return fmt.Errorf("read robots.txt: %w", err)
+		}
+		robotsTxt = robots.Parse(content)
+		source = "local file " + *localFile
+	} else {
+		registry := ratelimiter.NewRegistry(cfg)
+		defer registry.Close()
+
+		checker := robots.New(client.New(cfg, nil, registry, nil, nil, nil), nil, 0)
+		checker.SetUserAgent(cfg.UserAgent)
+		robotsTxt, source, err = checker.Rules(rawURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	printRobotsDecision(cfg, target, robotsTxt, source)
+	return nil
+}
+
+// printRobotsDecision выводит решение, примененную группу, правило, паузу и sitemap
+func printRobotsDecision(cfg *config.Config, target *url.URL, robotsTxt *robots.RobotsTxt, source string) {
+	decision := robotsTxt.DecideURL(cfg.UserAgent, target)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "URL:\t%s\n", target)
+	fmt.Fprintf(writer, "User-Agent:\t%s\n", cfg.UserAgent)
+	fmt.Fprintf(writer, "robots.txt:\t%s\n", source)
+
+	result := "allowed"
+	if !decision.Allowed {
+		result = "disallowed"
+	}
+	fmt.Fprintf(writer, "Result:\t%s\n", result)
+
+	group := decision.Group
+	if group == nil {
+		fmt.Fprintf(writer, "Group:\tnone matches, everything is allowed\n")
+	} else {
+		fmt.Fprintf(writer, "Group:\t%sUser-agent: %s\n", lineRef(group.Line), strings.Join(group.Agents, ", "))
+	}
+
+	switch {
+	case decision.Rule != nil:
+		fmt.Fprintf(writer, "Rule:\t%s%s\n", lineRef(decision.Rule.Line), decision.Rule)
+	case group != nil && target.EscapedPath() == "/robots.txt":
+		fmt.Fprintf(writer, "Rule:\t/robots.txt is always allowed\n")
+	case group != nil:
+		fmt.Fprintf(writer, "Rule:\tno rule matches, allowed by default\n")
+	}
+
+	switch {
+	case group == nil || group.CrawlDelay <= 0:
+		fmt.Fprintf(writer, "Crawl-delay:\tnot set\n")
+	case cfg.MaxCrawlDelay <= 0:
+		fmt.Fprintf(writer, "Crawl-delay:\t%s (ignored, -max-crawl-delay is 0)\n", group.CrawlDelay)
+	case group.CrawlDelay > cfg.MaxCrawlDelay:
+		fmt.Fprintf(writer, "Crawl-delay:\t%s (capped from %s)\n", cfg.MaxCrawlDelay, group.CrawlDelay)
+	default:
+		fmt.Fprintf(writer, "Crawl-delay:\t%s\n", group.CrawlDelay)
+	}
+
+	sitemaps := robotsTxt.Sitemaps()
+	if len(sitemaps) == 0 {
+		fmt.Fprintf(writer, "Sitemaps:\tnone\n")
+	}
+	for i, sitemap := range sitemaps {
+		label := ""
+		if i == 0 {
+			label = "Sitemaps:"
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", label, sitemap)
+	}
+}
+
+// lineRef ссылается на строку robots.txt; у правил, подставленных при
+// недоступности файла, строки нет
+func lineRef(line int) string {
+	if line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("line %d, ", line)
+}
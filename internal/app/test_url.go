@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+	"wget-go/internal/service/scheduler"
+	"wget-go/internal/service/scope"
+	"wget-go/internal/service/urlfilter"
+	"wget-go/pkg/utils"
+)
+
+// RunTestURLs объясняет для каждого -test-url, пропустят ли его границы
+// обхода и фильтры и какое правило это решило. Итог дает та же проверка,
+// что и в планировщике, поэтому он совпадает с обходом
+func RunTestURLs(cfg *config.Config) error {
+	crawlScope := scope.New(cfg)
+	filter := urlfilter.New(cfg)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for i, rawURL := range cfg.TestURLs {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+		fmt.Fprintf(writer, "URL:\t%s\n", rawURL)
+
+		if utils.IsDataURL(rawURL) {
+			fmt.Fprintf(writer, "Result:\tdownloaded, data: URLs are not subject to scope and filters\n")
+			continue
+		}
+
+		page := scheduler.CheckURL(crawlScope, filter, rawURL, domain.LinkNavigation)
+		requisite := scheduler.CheckURL(crawlScope, filter, rawURL, domain.LinkRequisite)
+		fmt.Fprintf(writer, "Normalized:\t%s\n", page.Normalized)
+
+		pageOK, pageRule := crawlScope.Allowed(rawURL, domain.LinkNavigation)
+		requisiteOK, requisiteRule := crawlScope.Allowed(rawURL, domain.LinkRequisite)
+		fmt.Fprintf(writer, "Scope as page:\t%s\n", verdict(pageOK, pageRule, "in scope"))
+		fmt.Fprintf(writer, "Scope as requisite:\t%s\n", verdict(requisiteOK, requisiteRule, "in scope"))
+
+		filterOK, filterRule := filter.Allowed(page.Normalized)
+		fmt.Fprintf(writer, "Filters:\t%s\n", verdict(filterOK, filterRule, filterRule))
+
+		switch {
+		case page.Allowed:
+			fmt.Fprintf(writer, "Result:\tdownloaded\n")
+		case requisite.Allowed:
+			fmt.Fprintf(writer, "Result:\tdownloaded only as a page requisite\n")
+		default:
+			fmt.Fprintf(writer, "Result:\tskipped, %s\n", page.Reason)
+		}
+	}
+	return writer.Flush()
+}
+
+// verdict описывает решение правила
+func verdict(allowed bool, rule, acceptedText string) string {
+	if allowed {
+		return "allowed, " + acceptedText
+	}
+	return "excluded, " + rule
+}
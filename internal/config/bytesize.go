@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize размер в байтах, задаваемый как в wget: 500, 10k, 5m, 2g
+type ByteSize int64
+
+// byteSizeUnits множители суффиксов (двоичные, как в wget)
+var byteSizeUnits = map[byte]int64{
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+	't': 1 << 40,
+}
+
+// ParseByteSize разбирает размер с необязательным суффиксом k, m, g или t
+func ParseByteSize(value string) (ByteSize, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "0" || value == "inf" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	if unit, exists := byteSizeUnits[value[len(value)-1]]; exists {
+		multiplier = unit
+		value = value[:len(value)-1]
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return ByteSize(number * float64(multiplier)), nil
+}
+
+// String реализует flag.Value
+func (b *ByteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+// Set реализует flag.Value
+func (b *ByteSize) Set(value string) error {
+	parsed, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// UnmarshalJSON принимает размер строкой ("5m") или числом байт
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case string:
+		return b.Set(value)
+	case float64:
+		*b = ByteSize(value)
+		return nil
+	default:
+		return fmt.Errorf("invalid size: %s", string(data))
+	}
+}
+
+// typeLimitList флаг вида Type=size, который можно указать несколько раз
+type typeLimitList map[string]ByteSize
+
+func (l *typeLimitList) String() string {
+	parts := make([]string, 0, len(*l))
+	for name, size := range *l {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, size))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *typeLimitList) Set(value string) error {
+	name, rawSize, found := strings.Cut(value, "=")
+	if !found {
+		return fmt.Errorf("expected Type=size, got %q", value)
+	}
+
+	size, err := ParseByteSize(rawSize)
+	if err != nil {
+		return err
+	}
+
+	if *l == nil {
+		*l = make(typeLimitList)
+	}
+	(*l)[strings.TrimSpace(name)] = size
+	return nil
+}
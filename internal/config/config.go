@@ -3,7 +3,12 @@ package config
 import (
 	"fmt"
 	"log"
+	"regexp"
+	"strings"
 	"time"
+	"wget-go/internal/domain"
+
+	"golang.org/x/net/publicsuffix"
 )
 
 // Config содержит конфигурацию приложения
@@ -16,6 +21,86 @@ type Config struct {
 	UserAgent     string
 	Timeout       time.Duration
 	RespectRobots bool
+
+	MaxCrawlDelay time.Duration // Верхняя граница паузы из Crawl-delay robots.txt (0 - не учитывать)
+	SkipLog       string        // Файл со списком пропущенных URL и причинами
+
+	Wait       time.Duration         // Пауза между запросами к одному хосту
+	RandomWait bool                  // Случайный множитель 0.5-1.5 для паузы
+	Hosts      map[string]HostConfig // Переопределения для отдельных хостов
+	ConfigFile string                // Путь к JSON файлу конфигурации
+
+	Adaptive bool // Адаптивная скорость по ответам сервера
+	MinRate  int  // Нижняя граница адаптивной скорости
+	MaxRate  int  // Верхняя граница адаптивной скорости (0 - настроенная скорость хоста)
+
+	Resolve     []string      // Переопределения DNS в формате host:port:addr
+	IPv4Only    bool          // Подключаться только по IPv4
+	IPv6Only    bool          // Подключаться только по IPv6
+	DNSCacheTTL time.Duration // Время жизни записей DNS кэша (0 - без кэша)
+
+	FTPActive   bool   // Активный режим FTP вместо пассивного
+	FTPUser     string // Пользователь FTP (по умолчанию anonymous)
+	FTPPassword string // Пароль FTP
+
+	ContentDisposition bool // Брать имя файла из заголовка Content-Disposition
+
+	Quota       ByteSize            // Суммарный объем скачивания за запуск (0 - без ограничения)
+	MaxFileSize ByteSize            // Максимальный размер одного ответа (0 - без ограничения)
+	TypeLimits  map[string]ByteSize // Максимальный размер по типу ресурса (HTML, Image, ...)
+
+	BlockPrivate bool     // Запрещать подключения к внутренним адресам (защита от SSRF)
+	AllowCIDRs   []string // Исключения из защиты от SSRF
+
+	Cache     bool     // Использовать дисковый HTTP кэш
+	CacheDir  string   // Каталог HTTP кэша
+	CacheSize ByteSize // Максимальный размер кэша (0 - без ограничения)
+	Offline   bool     // Отвечать только из кэша, не обращаясь к сети
+
+	HAR         string   // Путь к HAR файлу с записью HTTP обменов
+	HARBodySize ByteSize // Сколько байт тела ответа сохранять в HAR (0 - не сохранять)
+	HARRedact   bool     // Скрывать авторизацию и cookies в HAR
+
+	Segments        int      // Количество параллельных диапазонов для больших файлов (1 - без деления)
+	MinSegmentSize  ByteSize // Минимальный размер одного диапазона
+	MaxConnsPerHost int      // Максимум соединений к одному хосту (0 - без ограничения)
+
+	Verify   bool   // Проверять суммы из Digest заголовков и файлов .sha256/.md5
+	Metalink string // Путь к Metalink v4 документу со списком файлов
+
+	Sitemaps     bool   // Добавлять в очередь страницы из sitemap сайта
+	SitemapsOnly bool   // Скачивать только страницы из sitemap, не переходя по ссылкам
+	SitemapOrder string // Порядок страниц из sitemap: lastmod или priority (пусто - как в файле)
+
+	PageRequisites bool     // Скачивать ресурсы для отображения каждой страницы независимо от глубины
+	RequisiteHosts []string // Хосты, с которых разрешены ресурсы страниц (*.example.com - с поддоменами)
+
+	SpanHosts      bool     // Переходить на любые хосты, а не только на хост -url
+	Domains        []string // Домены, входящие в обход вместе с поддоменами
+	ExcludeDomains []string // Домены, исключенные из обхода вместе с поддоменами
+	NoParent       bool     // Не подниматься выше каталога -url
+	Schemes        []string // Разрешенные схемы (пусто - http, https и схема -url)
+	Ports          []int    // Разрешенные порты (пусто - любые)
+
+	AcceptRegex        []string // Скачивать только URL, подходящие под одно из выражений
+	RejectRegex        []string // Не скачивать URL, подходящие под выражение
+	AcceptGlob         []string // Скачивать только URL, подходящие под один из шаблонов с *
+	RejectGlob         []string // Не скачивать URL, подходящие под шаблон с *
+	IncludeDirectories []string // Скачивать только из этих каталогов
+	ExcludeDirectories []string // Не скачивать из этих каталогов
+	TestURLs           []string // URL для проверки правил без обхода
+
+	CrawlOrder string             // Стратегия обхода: bfs, dfs, requisites-first, best-first
+	URLWeights map[string]float64 // Веса шаблонов URL (регулярных выражений) для best-first
+
+	FrontierMemory int    // Сколько задач очереди держать в памяти до сброса на диск
+	FrontierDir    string // Каталог для сброшенных задач (пусто - системный временный)
+
+	Resume             string        // Файл состояния обхода для продолжения после прерывания
+	CheckpointInterval time.Duration // Как часто сохранять состояние обхода (0 - только при остановке)
+
+	Replay       string // Путь к архиву HAR или WARC для воспроизведения без сети
+	ReplayStrict bool   // Считать ошибкой URL, которого нет в архиве (иначе 404)
 }
 
 func MustLoad() *Config {
@@ -24,6 +109,13 @@ func MustLoad() *Config {
 	// Парсинг флагов
 	parsedCfg := parse(cfg)
 
+	// Загрузка файла конфигурации
+	if parsedCfg.ConfigFile != "" {
+		if err := loadFile(parsedCfg, parsedCfg.ConfigFile); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+	}
+
 	// Валидация конфигурации
 	if err := validate(parsedCfg); err != nil {
 		log.Fatalf("Invalid configuration: %v", err)
@@ -32,22 +124,42 @@ func MustLoad() *Config {
 	return parsedCfg
 }
 
+// DefaultCacheDir каталог HTTP кэша по умолчанию
+const DefaultCacheDir = ".wget-go-cache"
+
+// Default возвращает конфигурацию по умолчанию для подкоманд, не читающих флаги запуска
+func Default() *Config {
+	return defaultConfig()
+}
+
 // DefaultConfig возвращает конфигурацию по умолчанию
 func defaultConfig() *Config {
 	return &Config{
-		OutputDir:     "./download",
-		MaxDepth:      1,
-		Workers:       5,
-		RateLimit:     10,
-		UserAgent:     "Wget-Go/1.0",
-		Timeout:       30 * time.Second,
-		RespectRobots: true,
+		OutputDir:      "./download",
+		MaxDepth:       1,
+		Workers:        5,
+		RateLimit:      10,
+		UserAgent:      "Wget-Go/1.0",
+		Timeout:        30 * time.Second,
+		RespectRobots:  true,
+		MaxCrawlDelay:  10 * time.Second,
+		MinRate:        1,
+		DNSCacheTTL:    5 * time.Minute,
+		CacheDir:       DefaultCacheDir,
+		CacheSize:      1 << 30,
+		HARRedact:      true,
+		Segments:       1,
+		MinSegmentSize: 20 << 20,
+		CrawlOrder:     "bfs",
+		FrontierMemory: 100000,
+
+		CheckpointInterval: time.Minute,
 	}
 }
 
 // validate проверяет корректность конфигурации
 func validate(cfg *Config) error {
-	if cfg.URL == "" {
+	if cfg.URL == "" && cfg.Metalink == "" {
 		return fmt.Errorf("URL is required")
 	}
 	if cfg.MaxDepth < 0 {
@@ -59,5 +171,111 @@ func validate(cfg *Config) error {
 	if cfg.RateLimit < 1 {
 		return fmt.Errorf("rate limit must be at least 1")
 	}
+	if cfg.Wait < 0 {
+		return fmt.Errorf("wait cannot be negative")
+	}
+	if cfg.MaxCrawlDelay < 0 {
+		return fmt.Errorf("max crawl delay cannot be negative")
+	}
+	if cfg.MinRate < 1 {
+		return fmt.Errorf("min rate must be at least 1")
+	}
+	if cfg.MaxRate != 0 && cfg.MaxRate < cfg.MinRate {
+		return fmt.Errorf("max rate cannot be lower than min rate")
+	}
+	if cfg.Offline && !cfg.Cache {
+		return fmt.Errorf("-offline requires -cache")
+	}
+	if cfg.Segments < 1 {
+		return fmt.Errorf("segments must be at least 1")
+	}
+	if cfg.MaxConnsPerHost < 0 {
+		return fmt.Errorf("max connections per host cannot be negative")
+	}
+	if (cfg.Sitemaps || cfg.SitemapsOnly) && cfg.URL == "" {
+		return fmt.Errorf("-sitemaps requires -url")
+	}
+	switch cfg.SitemapOrder {
+	case "", "lastmod", "priority":
+	default:
+		return fmt.Errorf("unknown sitemap order %q (expected lastmod or priority)", cfg.SitemapOrder)
+	}
+	switch cfg.CrawlOrder {
+	case "bfs", "dfs", "requisites-first", "best-first":
+	default:
+		return fmt.Errorf("unknown crawl order %q (expected bfs, dfs, requisites-first or best-first)", cfg.CrawlOrder)
+	}
+	if err := validateDomains("-domains", cfg.Domains); err != nil {
+		return err
+	}
+	if err := validateDomains("-requisite-host", cfg.RequisiteHosts); err != nil {
+		return err
+	}
+	for _, port := range cfg.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("port %d is out of range 1-65535", port)
+		}
+	}
+	for _, pattern := range append(append([]string(nil), cfg.AcceptRegex...), cfg.RejectRegex...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid URL filter pattern %q: %w", pattern, err)
+		}
+	}
+	for pattern := range cfg.URLWeights {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid URL weight pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.FrontierMemory < 2 {
+		return fmt.Errorf("frontier memory must be at least 2 tasks")
+	}
+	if cfg.CheckpointInterval < 0 {
+		return fmt.Errorf("checkpoint interval cannot be negative")
+	}
+	if cfg.ReplayStrict && cfg.Replay == "" {
+		return fmt.Errorf("-replay-strict requires -replay")
+	}
+	if cfg.Replay != "" && cfg.HAR != "" {
+		return fmt.Errorf("-har cannot be combined with -replay")
+	}
+	if cfg.IPv4Only && cfg.IPv6Only {
+		return fmt.Errorf("-4 and -6 are mutually exclusive")
+	}
+	if cfg.DNSCacheTTL < 0 {
+		return fmt.Errorf("dns cache ttl cannot be negative")
+	}
+	for name := range cfg.TypeLimits {
+		if _, ok := domain.ParseResourceType(name); !ok {
+			return fmt.Errorf("unknown resource type in size limit: %s", name)
+		}
+	}
+	for host, hostCfg := range cfg.Hosts {
+		if hostCfg.RateLimit < 0 {
+			return fmt.Errorf("host %s: rate limit cannot be negative", host)
+		}
+		if hostCfg.Wait.Duration < 0 {
+			return fmt.Errorf("host %s: wait cannot be negative", host)
+		}
+	}
+	return nil
+}
+
+// validateDomains запрещает публичные суффиксы (com, co.uk, github.io) в
+// списках, расширяющих обход: вместе с поддоменами они охватывают
+// множество чужих сайтов
+func validateDomains(flagName string, domains []string) error {
+	for _, d := range domains {
+		name := strings.Trim(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "*."), ".")
+		if name == "" {
+			continue
+		}
+		// Неизвестный списку домен верхнего уровня (localhost) считается
+		// суффиксом неявно, такие имена разрешены
+		suffix, icann := publicsuffix.PublicSuffix(name)
+		if suffix == name && (icann || strings.Contains(name, ".")) {
+			return fmt.Errorf("%s %s is a public suffix and would include unrelated sites; "+
+				"use a registrable domain such as example.%s", flagName, d, name)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// HostConfig содержит переопределения вежливости для отдельного хоста
+type HostConfig struct {
+	RateLimit int      `json:"rate_limit"`
+	Wait      Duration `json:"wait"`
+}
+
+// Duration позволяет задавать time.Duration в JSON строкой вида "1.5s"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON разбирает длительность из строки или числа наносекунд
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("parse duration %q: %w", value, err)
+		}
+		d.Duration = parsed
+	case float64:
+		d.Duration = time.Duration(value)
+	default:
+		return fmt.Errorf("invalid duration: %s", string(data))
+	}
+	return nil
+}
+
+// fileConfig описывает содержимое файла конфигурации
+type fileConfig struct {
+	Hosts      map[string]HostConfig `json:"hosts"`
+	TypeLimits map[string]ByteSize   `json:"type_limits"`
+	URLWeights map[string]float64    `json:"url_weights"`
+	Filters    fileFilters           `json:"filters"`
+}
+
+// fileFilters правила отбора URL в файле конфигурации
+type fileFilters struct {
+	AcceptRegex        []string `json:"accept_regex"`
+	RejectRegex        []string `json:"reject_regex"`
+	AcceptGlob         []string `json:"accept_glob"`
+	RejectGlob         []string `json:"reject_glob"`
+	IncludeDirectories []string `json:"include_directories"`
+	ExcludeDirectories []string `json:"exclude_directories"`
+}
+
+// loadFile дополняет конфигурацию секциями из JSON файла
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+
+	if len(fc.Hosts) > 0 {
+		cfg.Hosts = fc.Hosts
+	}
+	// Лимиты из флагов имеют приоритет над файлом
+	for name, size := range fc.TypeLimits {
+		if _, exists := cfg.TypeLimits[name]; !exists {
+			if cfg.TypeLimits == nil {
+				cfg.TypeLimits = make(map[string]ByteSize)
+			}
+			cfg.TypeLimits[name] = size
+		}
+	}
+	// Веса из флагов тоже имеют приоритет
+	for pattern, weight := range fc.URLWeights {
+		if _, exists := cfg.URLWeights[pattern]; !exists {
+			if cfg.URLWeights == nil {
+				cfg.URLWeights = make(map[string]float64)
+			}
+			cfg.URLWeights[pattern] = weight
+		}
+	}
+	// Правила отбора из флагов и файла объединяются
+	cfg.AcceptRegex = append(cfg.AcceptRegex, fc.Filters.AcceptRegex...)
+	cfg.RejectRegex = append(cfg.RejectRegex, fc.Filters.RejectRegex...)
+	cfg.AcceptGlob = append(cfg.AcceptGlob, fc.Filters.AcceptGlob...)
+	cfg.RejectGlob = append(cfg.RejectGlob, fc.Filters.RejectGlob...)
+	cfg.IncludeDirectories = append(cfg.IncludeDirectories, fc.Filters.IncludeDirectories...)
+	cfg.ExcludeDirectories = append(cfg.ExcludeDirectories, fc.Filters.ExcludeDirectories...)
+	return nil
+}
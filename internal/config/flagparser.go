@@ -4,11 +4,96 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
+// stringList флаг, который можно указать несколько раз
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// commaList флаг со списком через запятую, который можно указать несколько раз
+type commaList []string
+
+func (l *commaList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *commaList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// portList флаг со списком портов через запятую, который можно указать несколько раз
+type portList []int
+
+func (l *portList) String() string {
+	parts := make([]string, len(*l))
+	for i, port := range *l {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *portList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		port, err := strconv.Atoi(item)
+		if err != nil {
+			return fmt.Errorf("invalid port %q", item)
+		}
+		*l = append(*l, port)
+	}
+	return nil
+}
+
+// weightList флаг вида pattern=weight, который можно указать несколько раз
+type weightList map[string]float64
+
+func (l *weightList) String() string {
+	parts := make([]string, 0, len(*l))
+	for pattern, weight := range *l {
+		parts = append(parts, fmt.Sprintf("%s=%g", pattern, weight))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *weightList) Set(value string) error {
+	// Шаблон может сам содержать '=', поэтому вес отделяется по последнему
+	separator := strings.LastIndex(value, "=")
+	if separator < 0 {
+		return fmt.Errorf("expected pattern=weight, got %q", value)
+	}
+
+	weight, err := strconv.ParseFloat(strings.TrimSpace(value[separator+1:]), 64)
+	if err != nil {
+		return fmt.Errorf("invalid weight in %q: %w", value, err)
+	}
+
+	if *l == nil {
+		*l = make(weightList)
+	}
+	(*l)[value[:separator]] = weight
+	return nil
+}
+
 // Parse извлекает конфигурацию из флагов
 func parse(cfg Config) *Config {
-	flag.StringVar(&cfg.URL, "url", "", "URL to download (required)")
+	flag.StringVar(&cfg.URL, "url", "", "URL to download (required unless -metalink is given)")
 	flag.StringVar(&cfg.OutputDir, "output", "./download", "Output directory")
 	flag.IntVar(&cfg.MaxDepth, "depth", cfg.MaxDepth, "Maximum recursion depth")
 	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "Number of concurrent workers")
@@ -16,6 +101,68 @@ func parse(cfg Config) *Config {
 	flag.StringVar(&cfg.UserAgent, "user-agent", cfg.UserAgent, "User-Agent header")
 	flag.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "Request timeout")
 	flag.BoolVar(&cfg.RespectRobots, "respect-robots", cfg.RespectRobots, "Respect robots.txt")
+	flag.StringVar(&cfg.SkipLog, "skip-log", cfg.SkipLog, "Write skipped URLs and the reason to this file")
+	flag.DurationVar(&cfg.MaxCrawlDelay, "max-crawl-delay", cfg.MaxCrawlDelay, "Cap for robots.txt Crawl-delay (0 ignores Crawl-delay)")
+	flag.DurationVar(&cfg.Wait, "wait", cfg.Wait, "Delay between requests to the same host")
+	flag.BoolVar(&cfg.RandomWait, "random-wait", cfg.RandomWait, "Randomize wait between 0.5 and 1.5 of -wait")
+	flag.BoolVar(&cfg.Adaptive, "adaptive", cfg.Adaptive, "Adapt per-host rate to server feedback")
+	flag.IntVar(&cfg.MinRate, "min-rate", cfg.MinRate, "Minimum adaptive requests per second")
+	flag.IntVar(&cfg.MaxRate, "max-rate", cfg.MaxRate, "Maximum adaptive requests per second (0 = configured host rate)")
+	flag.Var((*stringList)(&cfg.Resolve), "resolve", "Resolve host:port to addr, curl-style host:port:addr (repeatable)")
+	flag.BoolVar(&cfg.IPv4Only, "4", cfg.IPv4Only, "Connect to IPv4 addresses only")
+	flag.BoolVar(&cfg.IPv6Only, "6", cfg.IPv6Only, "Connect to IPv6 addresses only")
+	flag.DurationVar(&cfg.DNSCacheTTL, "dns-ttl", cfg.DNSCacheTTL, "DNS cache TTL (0 disables caching)")
+	flag.BoolVar(&cfg.FTPActive, "ftp-active", cfg.FTPActive, "Use active FTP mode instead of passive")
+	flag.StringVar(&cfg.FTPUser, "ftp-user", cfg.FTPUser, "FTP user (default anonymous)")
+	flag.StringVar(&cfg.FTPPassword, "ftp-password", cfg.FTPPassword, "FTP password")
+	flag.BoolVar(&cfg.ContentDisposition, "content-disposition", cfg.ContentDisposition, "Use filenames from Content-Disposition headers")
+	flag.Var(&cfg.Quota, "quota", "Total download quota per run, e.g. 500m (0 = unlimited)")
+	flag.Var(&cfg.MaxFileSize, "max-file-size", "Abort responses larger than this size, e.g. 50m (0 = unlimited)")
+	flag.Var((*typeLimitList)(&cfg.TypeLimits), "max-type-size", "Size cap per resource type, Type=size, e.g. Image=5m (repeatable)")
+	flag.BoolVar(&cfg.BlockPrivate, "block-private", cfg.BlockPrivate, "Refuse connections to loopback, private, link-local and metadata addresses")
+	flag.Var((*stringList)(&cfg.AllowCIDRs), "allow-cidr", "CIDR exempt from -block-private (repeatable)")
+	flag.BoolVar(&cfg.Cache, "cache", cfg.Cache, "Use on-disk HTTP cache between runs")
+	flag.StringVar(&cfg.CacheDir, "cache-dir", cfg.CacheDir, "HTTP cache directory")
+	flag.Var(&cfg.CacheSize, "cache-size", "Maximum HTTP cache size, e.g. 1g (0 = unlimited)")
+	flag.BoolVar(&cfg.Offline, "offline", cfg.Offline, "Serve only from the HTTP cache, never touch the network")
+	flag.StringVar(&cfg.HAR, "har", cfg.HAR, "Record HTTP traffic to a HAR 1.2 file")
+	flag.Var(&cfg.HARBodySize, "har-body-size", "Store up to this many bytes of each response body in the HAR, e.g. 64k (0 = no bodies)")
+	flag.BoolVar(&cfg.HARRedact, "har-redact", cfg.HARRedact, "Redact authorization headers and cookies in the HAR")
+	flag.IntVar(&cfg.Segments, "segments", cfg.Segments, "Download large files as this many parallel byte ranges")
+	flag.Var(&cfg.MinSegmentSize, "min-segment-size", "Do not split files into ranges smaller than this, e.g. 20m")
+	flag.IntVar(&cfg.MaxConnsPerHost, "max-conns-per-host", cfg.MaxConnsPerHost, "Maximum concurrent connections per host (0 = unlimited)")
+	flag.BoolVar(&cfg.Verify, "verify", cfg.Verify, "Verify downloads against Digest/Repr-Digest headers and .sha256/.md5 sidecar files")
+	flag.StringVar(&cfg.Metalink, "metalink", cfg.Metalink, "Download files listed in a Metalink v4 (.meta4) document")
+	flag.BoolVar(&cfg.Sitemaps, "sitemaps", cfg.Sitemaps, "Queue pages listed in sitemaps from robots.txt and /sitemap.xml")
+	flag.BoolVar(&cfg.SitemapsOnly, "sitemaps-only", cfg.SitemapsOnly, "Download only pages listed in sitemaps without following links")
+	flag.StringVar(&cfg.SitemapOrder, "sitemap-order", cfg.SitemapOrder, "Order of sitemap pages: lastmod (newest first) or priority (highest first)")
+	flag.BoolVar(&cfg.PageRequisites, "page-requisites", cfg.PageRequisites, "Download CSS, images and scripts needed to display every saved page, regardless of depth")
+	flag.BoolVar(&cfg.PageRequisites, "p", cfg.PageRequisites, "Shorthand for -page-requisites")
+	flag.Var((*stringList)(&cfg.RequisiteHosts), "requisite-host", "Host allowed to serve page requisites, *.example.com includes subdomains (repeatable)")
+	flag.BoolVar(&cfg.SpanHosts, "span-hosts", cfg.SpanHosts, "Follow links to any host, not only the -url host")
+	flag.BoolVar(&cfg.SpanHosts, "H", cfg.SpanHosts, "Shorthand for -span-hosts")
+	flag.Var((*commaList)(&cfg.Domains), "domains", "Comma-separated domains to crawl together with their subdomains (repeatable)")
+	flag.Var((*commaList)(&cfg.ExcludeDomains), "exclude-domains", "Comma-separated domains never to crawl, including subdomains (repeatable)")
+	flag.BoolVar(&cfg.NoParent, "no-parent", cfg.NoParent, "Do not ascend above the directory of -url")
+	flag.BoolVar(&cfg.NoParent, "np", cfg.NoParent, "Shorthand for -no-parent")
+	flag.Var((*commaList)(&cfg.Schemes), "schemes", "Comma-separated allowed URL schemes (default http,https and the -url scheme)")
+	flag.Var((*portList)(&cfg.Ports), "ports", "Comma-separated allowed ports (default any)")
+	flag.Var((*stringList)(&cfg.AcceptRegex), "accept-regex", "Download only URLs matching one of these regexps (repeatable)")
+	flag.Var((*stringList)(&cfg.RejectRegex), "reject-regex", "Skip URLs matching this regexp (repeatable)")
+	flag.Var((*stringList)(&cfg.AcceptGlob), "accept-glob", "Download only URLs matching one of these * patterns; /-patterns match path and query (repeatable)")
+	flag.Var((*stringList)(&cfg.RejectGlob), "reject-glob", "Skip URLs matching this * pattern; /-patterns match path and query (repeatable)")
+	flag.Var((*commaList)(&cfg.IncludeDirectories), "include-directories", "Comma-separated directories to download from (repeatable)")
+	flag.Var((*commaList)(&cfg.ExcludeDirectories), "exclude-directories", "Comma-separated directories to skip (repeatable)")
+	flag.Var((*stringList)(&cfg.TestURLs), "test-url", "Explain which scope and filter rules apply to this URL and exit (repeatable)")
+	flag.StringVar(&cfg.CrawlOrder, "order", cfg.CrawlOrder, "Crawl order: bfs (reproducible), dfs, requisites-first or best-first")
+	flag.Var((*weightList)(&cfg.URLWeights), "url-weight", "Best-first weight for URLs matching a regexp, pattern=weight (repeatable)")
+	flag.IntVar(&cfg.FrontierMemory, "frontier-memory", cfg.FrontierMemory, "Queued URLs kept in memory before spilling to disk")
+	flag.StringVar(&cfg.FrontierDir, "frontier-dir", cfg.FrontierDir, "Directory for queued URLs spilled to disk (default system temp)")
+	flag.StringVar(&cfg.Resume, "resume", cfg.Resume, "Save crawl state to this file and continue from it if it exists")
+	flag.DurationVar(&cfg.CheckpointInterval, "checkpoint-interval", cfg.CheckpointInterval, "How often to save crawl state with -resume (0 = only on stop)")
+	flag.StringVar(&cfg.Replay, "replay", cfg.Replay, "Answer HTTP requests from a HAR or WARC archive instead of the network")
+	flag.BoolVar(&cfg.ReplayStrict, "replay-strict", cfg.ReplayStrict, "Fail on URLs missing from the -replay archive instead of returning 404")
+	flag.StringVar(&cfg.ConfigFile, "config", cfg.ConfigFile, "Path to JSON config file")
 
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options]\n", os.Args[0])
@@ -23,6 +170,9 @@ func parse(cfg Config) *Config {
 		flag.PrintDefaults()
 		fmt.Fprintln(flag.CommandLine.Output(), "\nExample:")
 		fmt.Fprintln(flag.CommandLine.Output(), "  wget-go -url https://example.com -depth 2 -workers 10")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nCommands:")
+		fmt.Fprintln(flag.CommandLine.Output(), "  wget-go cache list|purge [-cache-dir DIR] [-stale] [URL...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "  wget-go robots-check URL [-user-agent UA] [-file robots.txt]")
 	}
 
 	flag.Parse()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 	"wget-go/internal/config"
 	httpserver "wget-go/internal/delivery/http-server"
 )
@@ -13,23 +14,58 @@ import (
 type HTTPClient struct {
 	client        *http.Client
 	userAgent     string
-	rateLimiter   httpserver.RateLimiter
+	rateLimiter   httpserver.HostLimiter
+	controller    httpserver.RateController
+	bodyLimiter   httpserver.BodyLimiter
 	robotsChecker httpserver.RobotsChecker
 }
 
 // New создает новый HTTP клиент
-func New(cfg *config.Config, rateLimiter httpserver.RateLimiter, robotsChecker httpserver.RobotsChecker) *HTTPClient {
+func New(
+	cfg *config.Config,
+	dialer httpserver.Dialer,
+	rateLimiter httpserver.HostLimiter,
+	controller httpserver.RateController,
+	bodyLimiter httpserver.BodyLimiter,
+	robotsChecker httpserver.RobotsChecker,
+	wrappers ...httpserver.TransportWrapper,
+) *HTTPClient {
+	var transport http.RoundTripper = newTransport(cfg, dialer)
+	for _, wrap := range wrappers {
+		transport = wrap(transport)
+	}
+
 	return &HTTPClient{
 		client: &http.Client{
+			Transport:     transport,
 			Timeout:       cfg.Timeout,
 			CheckRedirect: redirectPolicy,
 		},
 		userAgent:     cfg.UserAgent,
 		rateLimiter:   rateLimiter,
+		controller:    controller,
+		bodyLimiter:   bodyLimiter,
 		robotsChecker: robotsChecker,
 	}
 }
 
+// newTransport создает транспорт, подключающийся через переданный диалер
+func newTransport(cfg *config.Config, dialer httpserver.Dialer) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if dialer != nil {
+		transport.DialContext = dialer.DialContext
+	}
+
+	// Через прокси диалер видит только адрес прокси, и проверить
+	// конечный адрес назначения невозможно
+	if cfg.BlockPrivate {
+		transport.Proxy = nil
+	}
+
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	return transport
+}
+
 // redirectPolicy ограничивает количество редиректов
 func redirectPolicy(req *http.Request, redir []*http.Request) error {
 	if len(redir) >= 10 {
@@ -39,72 +75,177 @@ func redirectPolicy(req *http.Request, redir []*http.Request) error {
 }
 
 // Get выполняет HTTP GET запрос
-func (c *HTTPClient) Get(ctx context.Context, url string) ([]byte, string, error) {
+func (c *HTTPClient) Get(ctx context.Context, url string) (*httpserver.Response, error) {
 
 	// проверка robots.txt если включено
 	if c.robotsChecker != nil && !c.robotsChecker.IsAllowed(url) {
-		return nil, "", fmt.Errorf("access denied by robots.txt")
-	}
-
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, "", fmt.Errorf("rate limiter: %w", err)
+		return nil, fmt.Errorf("access denied by robots.txt")
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, "", fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if err := c.rateLimiter.Wait(ctx, req.URL.Host); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
 	c.setHeaders(req)
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("execute request: %w", err)
+		return nil, fmt.Errorf("execute request: %w", err)
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, &httpserver.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
-	content, err := io.ReadAll(resp.Body)
+	content, err := c.readBody(resp)
 	if err != nil {
-		return nil, "", fmt.Errorf("read response body: %w", err)
+		return nil, err
 	}
 
-	return content, resp.Header.Get("Content-Type"), nil
+	return newHTTPResponse(resp, content), nil
 }
 
 // Head выполняет HTTP HEAD запрос
-func (c *HTTPClient) Head(ctx context.Context, url string) (string, error) {
+func (c *HTTPClient) Head(ctx context.Context, url string) (*httpserver.Response, error) {
 	// Проверяем robots.txt если включено
 	if c.robotsChecker != nil && !c.robotsChecker.IsAllowed(url) {
-		return "", fmt.Errorf("access disallowed by robots.txt: %s", url)
-	}
-
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return "", fmt.Errorf("rate limiter: %w", err)
+		return nil, fmt.Errorf("access disallowed by robots.txt: %s", url)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if err := c.rateLimiter.Wait(ctx, req.URL.Host); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
 	}
 
 	c.setHeaders(req)
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return "", fmt.Errorf("execute request: %w", err)
+		return nil, fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, &httpserver.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return newHTTPResponse(resp, nil), nil
+}
+
+// GetRange выполняет GET запрос диапазона байт и копирует его в dst
+func (c *HTTPClient) GetRange(ctx context.Context, url string, start, end int64, ifRange string, dst io.Writer) (*httpserver.Response, error) {
+	if c.robotsChecker != nil && !c.robotsChecker.IsAllowed(url) {
+		return nil, fmt.Errorf("access denied by robots.txt")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if err := c.rateLimiter.Wait(ctx, req.URL.Host); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	c.setHeaders(req)
+	// Диапазоны относятся к несжатому представлению
+	req.Header.Set("Accept-Encoding", "identity")
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if ifRange != "" {
+		req.Header.Set("If-Range", ifRange)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, httpserver.ErrRangeIgnored
+	default:
+		return nil, &httpserver.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var rangeStart, rangeEnd int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/", &rangeStart, &rangeEnd); err != nil || rangeStart != start {
+		return nil, fmt.Errorf("unexpected Content-Range %q for bytes=%d-%d", resp.Header.Get("Content-Range"), start, end)
+	}
+
+	if _, err := io.Copy(dst, io.LimitReader(resp.Body, end-start+1)); err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	return newHTTPResponse(resp, nil), nil
+}
+
+// readBody читает тело ответа, прерывая загрузку при превышении лимита размера
+func (c *HTTPClient) readBody(resp *http.Response) ([]byte, error) {
+	var limit int64
+	if c.bodyLimiter != nil {
+		limit = c.bodyLimiter.Limit(resp.Header.Get("Content-Type"))
 	}
 
-	return resp.Header.Get("Content-Type"), nil
+	if limit <= 0 {
+		content, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read response body: %w", err)
+		}
+		return content, nil
+	}
+
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: Content-Length %d > %d", httpserver.ErrTooLarge, resp.ContentLength, limit)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", httpserver.ErrTooLarge, limit)
+	}
+	return content, nil
+}
+
+// do выполняет запрос и сообщает контроллеру скорости о результате
+func (c *HTTPClient) do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.client.Do(req)
+
+	if c.controller != nil {
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		c.controller.Observe(req.URL.Host, statusCode, time.Since(start), err)
+	}
+
+	return resp, err
+}
+
+// newHTTPResponse преобразует ответ net/http в Response
+func newHTTPResponse(resp *http.Response, body []byte) *httpserver.Response {
+	return &httpserver.Response{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Header:      resp.Header,
+		Body:        body,
+	}
 }
 
 // setHeaders устанавливает стандартные заголовки
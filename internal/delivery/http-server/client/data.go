@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+// defaultDataMediaType тип data: URL по умолчанию согласно RFC 2397
+const defaultDataMediaType = "text/plain;charset=US-ASCII"
+
+// DataClient декодирует встроенные ресурсы data: URL
+type DataClient struct{}
+
+// NewDataClient создает клиент для data: URL
+func NewDataClient() *DataClient {
+	return &DataClient{}
+}
+
+// Get декодирует содержимое data: URL
+func (c *DataClient) Get(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	content, mediaType, err := DecodeDataURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(rawURL, mediaType, content), nil
+}
+
+// Head возвращает тип содержимого data: URL
+func (c *DataClient) Head(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	mediaType, _, _, err := splitDataURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(rawURL, mediaType, nil), nil
+}
+
+// DecodeDataURL разбирает data: URL и возвращает содержимое и его тип
+func DecodeDataURL(rawURL string) ([]byte, string, error) {
+	mediaType, isBase64, payload, err := splitDataURL(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("unescape data url: %w", err)
+	}
+
+	if !isBase64 {
+		return []byte(decoded), mediaType, nil
+	}
+
+	// Пробелы и переводы строк внутри base64 допустимы и игнорируются
+	decoded = strings.Join(strings.Fields(decoded), "")
+	content, err := base64.StdEncoding.DecodeString(decoded)
+	if err != nil {
+		content, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(decoded, "="))
+		if err != nil {
+			return nil, "", fmt.Errorf("decode base64 data url: %w", err)
+		}
+	}
+
+	return content, mediaType, nil
+}
+
+// splitDataURL разделяет data: URL на тип, признак base64 и полезную нагрузку
+func splitDataURL(rawURL string) (string, bool, string, error) {
+	if len(rawURL) < 5 || !strings.EqualFold(rawURL[:5], "data:") {
+		return "", false, "", fmt.Errorf("not a data url")
+	}
+
+	header, payload, found := strings.Cut(rawURL[5:], ",")
+	if !found {
+		return "", false, "", fmt.Errorf("invalid data url: missing comma")
+	}
+
+	isBase64 := false
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		isBase64 = true
+		header = header[:len(header)-len(";base64")]
+	}
+
+	mediaType := header
+	if mediaType == "" {
+		mediaType = defaultDataMediaType
+	} else if strings.HasPrefix(mediaType, ";") {
+		mediaType = "text/plain" + mediaType
+	}
+
+	return mediaType, isBase64, payload, nil
+}
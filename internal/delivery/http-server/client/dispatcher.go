@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+// SchemeClient направляет запросы клиенту, зарегистрированному для схемы URL
+type SchemeClient struct {
+	clients map[string]httpserver.Client
+}
+
+// NewSchemeClient создает диспетчер по схемам
+func NewSchemeClient() *SchemeClient {
+	return &SchemeClient{clients: make(map[string]httpserver.Client)}
+}
+
+// Register регистрирует клиент для одной или нескольких схем
+func (s *SchemeClient) Register(client httpserver.Client, schemes ...string) {
+	for _, scheme := range schemes {
+		s.clients[strings.ToLower(scheme)] = client
+	}
+}
+
+// Get выполняет запрос через клиент, соответствующий схеме URL
+func (s *SchemeClient) Get(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	client, err := s.clientFor(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(ctx, rawURL)
+}
+
+// Head выполняет HEAD запрос через клиент, соответствующий схеме URL
+func (s *SchemeClient) Head(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	client, err := s.clientFor(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return client.Head(ctx, rawURL)
+}
+
+// GetRange скачивает диапазон байт через клиент схемы, если он это умеет
+func (s *SchemeClient) GetRange(ctx context.Context, rawURL string, start, end int64, ifRange string, dst io.Writer) (*httpserver.Response, error) {
+	client, err := s.clientFor(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeClient, ok := client.(httpserver.RangeClient)
+	if !ok {
+		return nil, httpserver.ErrRangeNotSupported
+	}
+	return rangeClient.GetRange(ctx, rawURL, start, end, ifRange, dst)
+}
+
+// clientFor возвращает клиент для схемы URL
+func (s *SchemeClient) clientFor(rawURL string) (httpserver.Client, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	client, exists := s.clients[strings.ToLower(parsed.Scheme)]
+	if !exists {
+		return nil, fmt.Errorf("unsupported scheme: %q", parsed.Scheme)
+	}
+	return client, nil
+}
+
+// newResponse создает успешный ответ для клиентов без HTTP заголовков
+func newResponse(rawURL, contentType string, body []byte) *httpserver.Response {
+	return &httpserver.Response{
+		URL:         rawURL,
+		StatusCode:  http.StatusOK,
+		ContentType: contentType,
+		Header:      http.Header{"Content-Type": []string{contentType}},
+		Body:        body,
+	}
+}
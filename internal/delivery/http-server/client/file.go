@@ -0,0 +1,171 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+// FileClient читает ресурсы file:// из локальной файловой системы
+type FileClient struct{}
+
+// NewFileClient создает клиент для file:// URL
+func NewFileClient() *FileClient {
+	return &FileClient{}
+}
+
+// Get читает файл; для каталога возвращает index.html или сгенерированный
+// листинг. Ответ для каталога отдается по URL со слешем на конце, как
+// сервер HTTP перенаправляет /docs на /docs/, чтобы относительные ссылки
+// index.html разрешались внутри каталога
+func (c *FileClient) Get(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	path, err := filePath(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	if info.IsDir() {
+		dirURL, err := directoryURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		index := filepath.Join(path, "index.html")
+		if _, err := os.Stat(index); err != nil {
+			listing, err := directoryListing(path, dirURL.Path)
+			if err != nil {
+				return nil, err
+			}
+			return newResponse(dirURL.String(), "text/html; charset=utf-8", listing), nil
+		}
+
+		content, err := os.ReadFile(index)
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+		return newResponse(dirURL.String(), "text/html; charset=utf-8", content), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	return newResponse(rawURL, detectContentType(path, content), content), nil
+}
+
+// Head возвращает Content-Type файла без чтения содержимого целиком
+func (c *FileClient) Head(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	path, err := filePath(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	if info.IsDir() {
+		return newResponse(rawURL, "text/html; charset=utf-8", nil), nil
+	}
+
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return newResponse(rawURL, contentType, nil), nil
+	}
+
+	// Расширение неизвестно - определяем по первым байтам
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, _ := file.Read(head)
+	return newResponse(rawURL, http.DetectContentType(head[:n]), nil), nil
+}
+
+// filePath извлекает путь в файловой системе из file:// URL
+func filePath(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
+	}
+
+	if parsed.Host != "" && parsed.Host != "localhost" {
+		return "", fmt.Errorf("remote file host not supported: %s", parsed.Host)
+	}
+
+	return filepath.FromSlash(parsed.Path), nil
+}
+
+// directoryURL возвращает URL каталога со слешем на конце пути
+func directoryURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	parsed.Fragment = ""
+	if !strings.HasSuffix(parsed.Path, "/") {
+		parsed.Path += "/"
+		parsed.RawPath = ""
+	}
+	return parsed, nil
+}
+
+// detectContentType определяет тип по расширению или содержимому
+func detectContentType(path string, content []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(content)
+}
+
+// directoryListing генерирует HTML со ссылками на содержимое каталога,
+// чтобы экстрактор мог рекурсивно обойти его. Ссылки абсолютные от корня,
+// как в листинге FTP, и не зависят от слеша в URL каталога
+func directoryListing(dir, urlPath string) ([]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString("<html><body><ul>\n")
+	for _, name := range names {
+		href := (&url.URL{Path: path.Join(urlPath, name)}).String()
+		if strings.HasSuffix(name, "/") {
+			href += "/"
+		}
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(name))
+	}
+	buf.WriteString("</ul></body></html>\n")
+
+	return buf.Bytes(), nil
+}
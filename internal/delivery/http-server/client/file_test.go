@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resolve разрешает ссылку страницы от URL ответа, как это делает экстрактор
+func resolve(t *testing.T, base, href string) string {
+	t.Helper()
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return baseURL.ResolveReference(ref).String()
+}
+
+func TestFileDirectoryListing(t *testing.T) {
+	root := filepath.ToSlash(t.TempDir())
+	writeTestFile(t, root+"/site/a.html", "a")
+	writeTestFile(t, root+"/site/docs/b.html", "b")
+
+	for _, rawURL := range []string{"file://" + root + "/site", "file://" + root + "/site/"} {
+		resp, err := NewFileClient().Get(context.Background(), rawURL)
+		if err != nil {
+			t.Fatalf("Get %s: %v", rawURL, err)
+		}
+		if resp.URL != "file://"+root+"/site/" {
+			t.Errorf("Get %s: response URL %s, want the directory URL with a slash", rawURL, resp.URL)
+		}
+
+		listing := string(resp.Body)
+		for _, want := range []string{
+			`href="` + root + `/site/a.html"`,
+			`href="` + root + `/site/docs/"`,
+		} {
+			if !strings.Contains(listing, want) {
+				t.Errorf("Get %s: listing has no %s:\n%s", rawURL, want, listing)
+			}
+		}
+	}
+}
+
+func TestFileDirectoryIndex(t *testing.T) {
+	root := filepath.ToSlash(t.TempDir())
+	writeTestFile(t, root+"/site/index.html", `<a href="a.html">a</a>`)
+
+	resp, err := NewFileClient().Get(context.Background(), "file://"+root+"/site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != `<a href="a.html">a</a>` {
+		t.Fatalf("body = %q, want index.html", resp.Body)
+	}
+	if !strings.HasPrefix(resp.ContentType, "text/html") {
+		t.Errorf("content type = %s", resp.ContentType)
+	}
+
+	// Относительная ссылка index.html остается внутри каталога
+	if got, want := resolve(t, resp.URL, "a.html"), "file://"+root+"/site/a.html"; got != want {
+		t.Fatalf("a.html resolves to %s, want %s", got, want)
+	}
+}
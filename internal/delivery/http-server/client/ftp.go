@@ -0,0 +1,538 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"wget-go/internal/config"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+const (
+	// ftpDefaultPort стандартный порт управляющего соединения
+	ftpDefaultPort = "21"
+	// ftpAnonymousUser и ftpAnonymousPassword используются без явных учетных данных
+	ftpAnonymousUser     = "anonymous"
+	ftpAnonymousPassword = "wget-go@"
+	// ftpResumeAttempts сколько раз докачивать файл через REST после обрыва
+	ftpResumeAttempts = 3
+)
+
+// FTPClient реализует контракт клиента для ftp:// URL.
+// Для каталогов Get возвращает сгенерированный HTML листинг, чтобы
+// экстрактор мог рекурсивно обойти дерево файлов
+type FTPClient struct {
+	dialer      httpserver.Dialer
+	rateLimiter httpserver.HostLimiter
+	bodyLimiter httpserver.BodyLimiter
+	timeout     time.Duration
+	active      bool
+	user        string
+	password    string
+}
+
+// NewFTP создает FTP клиент. bodyLimiter ограничивает размер файлов так
+// же, как тела HTTP ответов (nil - без ограничения)
+func NewFTP(
+	cfg *config.Config,
+	dialer httpserver.Dialer,
+	rateLimiter httpserver.HostLimiter,
+	bodyLimiter httpserver.BodyLimiter,
+) *FTPClient {
+	return &FTPClient{
+		dialer:      dialer,
+		rateLimiter: rateLimiter,
+		bodyLimiter: bodyLimiter,
+		timeout:     cfg.Timeout,
+		active:      cfg.FTPActive,
+		user:        cfg.FTPUser,
+		password:    cfg.FTPPassword,
+	}
+}
+
+// Get скачивает файл или возвращает листинг каталога
+func (c *FTPClient) Get(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	session, err := c.connect(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer session.close()
+
+	filePath := ftpPath(target)
+	if strings.HasSuffix(filePath, "/") || session.isDir(filePath) {
+		entries, err := session.list(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return newResponse(rawURL, "text/html; charset=utf-8", ftpListing(filePath, entries)), nil
+	}
+
+	content, err := c.retrieve(ctx, target, session, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(rawURL, detectContentType(filePath, content), content), nil
+}
+
+// Head проверяет существование ресурса и возвращает его тип
+func (c *FTPClient) Head(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	session, err := c.connect(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer session.close()
+
+	filePath := ftpPath(target)
+	if strings.HasSuffix(filePath, "/") || session.isDir(filePath) {
+		return newResponse(rawURL, "text/html; charset=utf-8", nil), nil
+	}
+
+	if _, _, err := session.cmd(2, "SIZE %s", filePath); err != nil {
+		return nil, fmt.Errorf("ftp size: %w", err)
+	}
+
+	return newResponse(rawURL, ftpContentType(filePath), nil), nil
+}
+
+// retrieve скачивает файл, докачивая его через REST при обрыве передачи.
+// Сессии, открытые для докачки, закрываются здесь же; исходную сессию
+// закрывает вызывающий
+func (c *FTPClient) retrieve(ctx context.Context, target *url.URL, session *ftpSession, filePath string) ([]byte, error) {
+	var limit int64
+	if c.bodyLimiter != nil {
+		limit = c.bodyLimiter.Limit(ftpContentType(filePath))
+	}
+
+	current := session
+	defer func() {
+		if current != session {
+			current.close()
+		}
+	}()
+
+	var buf bytes.Buffer
+	for attempt := 0; ; attempt++ {
+		err := current.retr(filePath, &buf, limit)
+		if err == nil {
+			return buf.Bytes(), nil
+		}
+		if attempt >= ftpResumeAttempts || isPermanentFTPError(err) ||
+			errors.Is(err, httpserver.ErrTooLarge) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		log.Printf("FTP transfer of %s interrupted at %d bytes, resuming: %v", target.Redacted(), buf.Len(), err)
+
+		current.close()
+		current, err = c.connect(ctx, target)
+		if err != nil {
+			current = session
+			return nil, err
+		}
+	}
+}
+
+// connect устанавливает управляющее соединение и выполняет вход
+func (c *FTPClient) connect(ctx context.Context, target *url.URL) (*ftpSession, error) {
+	host := target.Host
+	if target.Port() == "" {
+		host = net.JoinHostPort(target.Hostname(), ftpDefaultPort)
+	}
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx, target.Host); err != nil {
+			return nil, fmt.Errorf("rate limiter: %w", err)
+		}
+	}
+
+	conn, err := c.dial(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("ftp connect: %w", err)
+	}
+
+	session := &ftpSession{
+		client:  c,
+		ctx:     ctx,
+		conn:    conn,
+		text:    textproto.NewConn(conn),
+		timeout: c.timeout,
+	}
+	session.touch()
+
+	if _, _, err := session.text.ReadResponse(2); err != nil {
+		session.text.Close()
+		return nil, fmt.Errorf("ftp greeting: %w", err)
+	}
+
+	user, password := c.credentials(target)
+	if err := session.login(user, password); err != nil {
+		session.text.Close()
+		return nil, err
+	}
+
+	if _, _, err := session.cmd(2, "TYPE I"); err != nil {
+		session.close()
+		return nil, fmt.Errorf("ftp binary mode: %w", err)
+	}
+
+	return session, nil
+}
+
+// credentials возвращает учетные данные из URL, конфигурации или анонимные
+func (c *FTPClient) credentials(target *url.URL) (string, string) {
+	if target.User != nil {
+		password, _ := target.User.Password()
+		return target.User.Username(), password
+	}
+	if c.user != "" {
+		return c.user, c.password
+	}
+	return ftpAnonymousUser, ftpAnonymousPassword
+}
+
+// dial подключается к адресу через общий диалер
+func (c *FTPClient) dial(ctx context.Context, address string) (net.Conn, error) {
+	if c.dialer != nil {
+		return c.dialer.DialContext(ctx, "tcp", address)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", address)
+}
+
+// ftpSession управляющее соединение с FTP сервером
+type ftpSession struct {
+	client  *FTPClient
+	ctx     context.Context
+	conn    net.Conn
+	text    *textproto.Conn
+	timeout time.Duration
+}
+
+// touch продлевает таймаут соединения
+func (s *ftpSession) touch() {
+	if s.timeout > 0 {
+		s.conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+}
+
+// cmd отправляет команду и ожидает ответ с кодом указанного класса
+func (s *ftpSession) cmd(expect int, format string, args ...interface{}) (int, string, error) {
+	s.touch()
+	if err := s.text.PrintfLine(format, args...); err != nil {
+		return 0, "", err
+	}
+	return s.text.ReadResponse(expect)
+}
+
+// login выполняет вход на сервер
+func (s *ftpSession) login(user, password string) error {
+	code, msg, err := s.cmd(0, "USER %s", user)
+	if err != nil {
+		return fmt.Errorf("ftp login: %w", err)
+	}
+
+	switch code / 100 {
+	case 2:
+		return nil
+	case 3:
+		// Сервер ожидает пароль
+	default:
+		return fmt.Errorf("ftp login: %w", &textproto.Error{Code: code, Msg: msg})
+	}
+
+	if _, _, err := s.cmd(2, "PASS %s", password); err != nil {
+		return fmt.Errorf("ftp login: %w", err)
+	}
+	return nil
+}
+
+// isDir проверяет, является ли путь каталогом, переходя в него
+func (s *ftpSession) isDir(dirPath string) bool {
+	_, _, err := s.cmd(2, "CWD %s", dirPath)
+	return err == nil
+}
+
+// retr скачивает файл в buf, продолжая с текущей длины buf через REST.
+// Передача прерывается, если файл больше limit байт (0 - без ограничения)
+func (s *ftpSession) retr(filePath string, buf *bytes.Buffer, limit int64) error {
+	data, err := s.openData()
+	if err != nil {
+		return err
+	}
+	defer data.close()
+
+	if offset := buf.Len(); offset > 0 {
+		if _, _, err := s.cmd(3, "REST %d", offset); err != nil {
+			// Сервер не поддерживает докачку - начинаем заново
+			log.Printf("FTP server does not support REST, restarting transfer: %v", err)
+			buf.Reset()
+		}
+	}
+
+	if _, _, err := s.cmd(1, "RETR %s", filePath); err != nil {
+		return fmt.Errorf("ftp retr: %w", err)
+	}
+
+	conn, err := data.accept()
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader = conn
+	if limit > 0 {
+		reader = io.LimitReader(conn, limit-int64(buf.Len())+1)
+	}
+	if _, err := io.Copy(buf, reader); err != nil {
+		return fmt.Errorf("ftp transfer: %w", err)
+	}
+	if limit > 0 && int64(buf.Len()) > limit {
+		return fmt.Errorf("%w: more than %d bytes", httpserver.ErrTooLarge, limit)
+	}
+	data.close()
+
+	s.touch()
+	if _, _, err := s.text.ReadResponse(2); err != nil {
+		return fmt.Errorf("ftp transfer: %w", err)
+	}
+	return nil
+}
+
+// list возвращает содержимое каталога через MLSD, а при отсутствии поддержки - через LIST
+func (s *ftpSession) list(dirPath string) ([]ftpEntry, error) {
+	lines, err := s.transferLines("MLSD", dirPath)
+	if err == nil {
+		return parseMLSD(lines), nil
+	}
+
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) || protoErr.Code/100 != 5 {
+		return nil, err
+	}
+
+	lines, err = s.transferLines("LIST", dirPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseLIST(lines), nil
+}
+
+// transferLines выполняет команду листинга и читает строки из канала данных
+func (s *ftpSession) transferLines(command, dirPath string) ([]string, error) {
+	data, err := s.openData()
+	if err != nil {
+		return nil, err
+	}
+	defer data.close()
+
+	if _, _, err := s.cmd(1, "%s %s", command, dirPath); err != nil {
+		return nil, err
+	}
+
+	conn, err := data.accept()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("ftp %s: %w", strings.ToLower(command), err)
+	}
+	data.close()
+
+	s.touch()
+	if _, _, err := s.text.ReadResponse(2); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// openData открывает канал данных в пассивном или активном режиме
+func (s *ftpSession) openData() (*ftpDataChannel, error) {
+	if s.client.active {
+		return s.openActive()
+	}
+	return s.openPassive()
+}
+
+// openPassive запрашивает у сервера порт через EPSV или PASV и подключается к нему
+func (s *ftpSession) openPassive() (*ftpDataChannel, error) {
+	port, err := s.passivePort()
+	if err != nil {
+		return nil, err
+	}
+
+	// Подключаемся к адресу управляющего соединения, игнорируя IP из ответа PASV
+	remoteHost, _, err := net.SplitHostPort(s.conn.RemoteAddr().String())
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := s.client.dial(s.ctx, net.JoinHostPort(remoteHost, strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("ftp data connection: %w", err)
+	}
+	return &ftpDataChannel{conn: conn, timeout: s.timeout}, nil
+}
+
+// passivePort возвращает порт данных из ответа EPSV или PASV
+func (s *ftpSession) passivePort() (int, error) {
+	if _, msg, err := s.cmd(2, "EPSV"); err == nil {
+		start := strings.Index(msg, "(|||")
+		end := strings.LastIndex(msg, "|)")
+		if start >= 0 && end > start+4 {
+			if port, err := strconv.Atoi(msg[start+4 : end]); err == nil {
+				return port, nil
+			}
+		}
+	}
+
+	_, msg, err := s.cmd(2, "PASV")
+	if err != nil {
+		return 0, fmt.Errorf("ftp passive mode: %w", err)
+	}
+
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start < 0 || end <= start {
+		return 0, fmt.Errorf("ftp passive mode: malformed reply %q", msg)
+	}
+
+	fields := strings.Split(msg[start+1:end], ",")
+	if len(fields) != 6 {
+		return 0, fmt.Errorf("ftp passive mode: malformed reply %q", msg)
+	}
+
+	high, errHigh := strconv.Atoi(strings.TrimSpace(fields[4]))
+	low, errLow := strconv.Atoi(strings.TrimSpace(fields[5]))
+	if errHigh != nil || errLow != nil {
+		return 0, fmt.Errorf("ftp passive mode: malformed reply %q", msg)
+	}
+	return high<<8 | low, nil
+}
+
+// openActive открывает локальный порт и сообщает его серверу через EPRT или PORT
+func (s *ftpSession) openActive() (*ftpDataChannel, error) {
+	localHost, _, err := net.SplitHostPort(s.conn.LocalAddr().String())
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(localHost, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("ftp active mode: %w", err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	ip := net.ParseIP(localHost)
+
+	if ip4 := ip.To4(); ip4 != nil {
+		_, _, err = s.cmd(2, "PORT %d,%d,%d,%d,%d,%d", ip4[0], ip4[1], ip4[2], ip4[3], port>>8, port&0xff)
+	} else {
+		_, _, err = s.cmd(2, "EPRT |2|%s|%d|", localHost, port)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("ftp active mode: %w", err)
+	}
+
+	return &ftpDataChannel{listener: listener, timeout: s.timeout}, nil
+}
+
+// close завершает сессию
+func (s *ftpSession) close() {
+	if s.text == nil {
+		return
+	}
+	s.conn.SetDeadline(time.Now().Add(time.Second))
+	s.text.PrintfLine("QUIT")
+	s.text.Close()
+	s.text = nil
+}
+
+// ftpDataChannel канал данных: готовое соединение (пассивный режим)
+// или слушающий сокет, ожидающий подключения сервера (активный режим)
+type ftpDataChannel struct {
+	conn     net.Conn
+	listener net.Listener
+	timeout  time.Duration
+}
+
+// accept возвращает соединение канала данных
+func (d *ftpDataChannel) accept() (net.Conn, error) {
+	if d.conn == nil {
+		if tcpListener, ok := d.listener.(*net.TCPListener); ok && d.timeout > 0 {
+			tcpListener.SetDeadline(time.Now().Add(d.timeout))
+		}
+
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return nil, fmt.Errorf("ftp data connection: %w", err)
+		}
+		d.conn = conn
+	}
+
+	if d.timeout > 0 {
+		d.conn.SetDeadline(time.Now().Add(d.timeout))
+	}
+	return d.conn, nil
+}
+
+// close закрывает канал данных
+func (d *ftpDataChannel) close() {
+	if d.conn != nil {
+		d.conn.Close()
+	}
+	if d.listener != nil {
+		d.listener.Close()
+	}
+}
+
+// ftpPath возвращает путь на сервере из URL
+func ftpPath(target *url.URL) string {
+	if target.Path == "" {
+		return "/"
+	}
+	return target.Path
+}
+
+// ftpContentType определяет тип файла по расширению
+func ftpContentType(filePath string) string {
+	if contentType := mime.TypeByExtension(path.Ext(filePath)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// isPermanentFTPError проверяет, что сервер окончательно отказал (коды 5xx)
+func isPermanentFTPError(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code/100 == 5
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ftpEntry элемент листинга FTP каталога
+type ftpEntry struct {
+	Name string
+	Dir  bool
+}
+
+// parseMLSD разбирает машиночитаемый листинг MLSD (RFC 3659):
+// "type=file;size=42;modify=20240101000000; name"
+func parseMLSD(lines []string) []ftpEntry {
+	var entries []ftpEntry
+
+	for _, line := range lines {
+		facts, name, found := strings.Cut(line, " ")
+		if !found || name == "" {
+			continue
+		}
+
+		entry := ftpEntry{Name: name}
+		skip := false
+		for _, fact := range strings.Split(facts, ";") {
+			key, value, _ := strings.Cut(fact, "=")
+			if !strings.EqualFold(key, "type") {
+				continue
+			}
+
+			switch strings.ToLower(value) {
+			case "dir":
+				entry.Dir = true
+			case "cdir", "pdir":
+				skip = true
+			}
+		}
+
+		if !skip {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// parseLIST разбирает листинг LIST в формате Unix ls -l или MS-DOS
+func parseLIST(lines []string) []ftpEntry {
+	var entries []ftpEntry
+
+	for _, line := range lines {
+		entry, ok := parseUnixListLine(line)
+		if !ok {
+			entry, ok = parseDOSListLine(line)
+		}
+		if !ok || entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// parseUnixListLine разбирает строку вида
+// "drwxr-xr-x 2 owner group 4096 Jan 01 12:00 name"
+func parseUnixListLine(line string) (ftpEntry, bool) {
+	if line == "" || !strings.ContainsRune("-dl", rune(line[0])) {
+		return ftpEntry{}, false
+	}
+
+	// Имя начинается после восьми полей и может содержать пробелы
+	name, ok := afterFields(line, 8)
+	if !ok {
+		return ftpEntry{}, false
+	}
+
+	// Для символических ссылок отбрасываем цель
+	if line[0] == 'l' {
+		name, _, _ = strings.Cut(name, " -> ")
+	}
+
+	return ftpEntry{Name: name, Dir: line[0] == 'd'}, name != ""
+}
+
+// parseDOSListLine разбирает строку вида "01-01-24  10:00AM  <DIR>  name"
+func parseDOSListLine(line string) (ftpEntry, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || !strings.Contains(fields[0], "-") {
+		return ftpEntry{}, false
+	}
+
+	// Имя - все после третьего поля, включая пробелы
+	name, ok := afterFields(line, 3)
+	if !ok {
+		return ftpEntry{}, false
+	}
+
+	return ftpEntry{Name: name, Dir: fields[2] == "<DIR>"}, name != ""
+}
+
+// afterFields возвращает остаток строки после n полей, разделенных
+// пробелами или табуляцией. Остаток может сам содержать пробелы
+func afterFields(line string, n int) (string, bool) {
+	rest := line
+	for i := 0; i < n; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		idx := strings.IndexAny(rest, " \t")
+		if idx < 0 {
+			return "", false
+		}
+		rest = rest[idx:]
+	}
+	return strings.TrimLeft(rest, " \t"), true
+}
+
+// ftpListing генерирует HTML листинг каталога с абсолютными ссылками
+func ftpListing(dirPath string, entries []ftpEntry) []byte {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("<html><body><ul>\n")
+	for _, entry := range entries {
+		entryPath := path.Join("/", dirPath, entry.Name)
+		name := entry.Name
+		if entry.Dir {
+			entryPath += "/"
+			name += "/"
+		}
+
+		href := (&url.URL{Path: entryPath}).String()
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(name))
+	}
+	buf.WriteString("</ul></body></html>\n")
+
+	return buf.Bytes()
+}
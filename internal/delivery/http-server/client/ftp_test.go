@@ -0,0 +1,362 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wget-go/internal/config"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+// testFTPServer FTP сервер на loopback с файлами и каталогами в памяти
+type testFTPServer struct {
+	listener net.Listener
+
+	files    map[string]string // Путь - содержимое
+	dirs     map[string]string // Путь каталога - вывод LIST
+	mlsd     map[string]string // Путь каталога - вывод MLSD (nil - команда не поддерживается)
+	noEPSV   bool              // Отвечать 500 на EPSV, чтобы клиент перешел на PASV
+	cutFirst int               // Оборвать первую передачу RETR после стольких байт (0 - не обрывать)
+
+	mu       sync.Mutex
+	commands []string // Полученные команды, кроме USER и PASS
+	cut      bool
+}
+
+func startTestFTPServer(t *testing.T, server *testFTPServer) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.listener = listener
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (s *testFTPServer) record(command string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, command)
+}
+
+func (s *testFTPServer) received(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []string
+	for _, command := range s.commands {
+		if strings.HasPrefix(command, prefix) {
+			matched = append(matched, command)
+		}
+	}
+	return matched
+}
+
+// serve обслуживает одно управляющее соединение
+func (s *testFTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var passive net.Listener
+	var activeAddr string
+	var offset int64
+	defer func() {
+		if passive != nil {
+			passive.Close()
+		}
+	}()
+
+	// openData подключает канал данных в режиме, выбранном клиентом
+	openData := func() (net.Conn, error) {
+		if passive != nil {
+			defer func() { passive.Close(); passive = nil }()
+			return passive.Accept()
+		}
+		return net.Dial("tcp", activeAddr)
+	}
+
+	reply("220 test server ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command, arg, _ := strings.Cut(line, " ")
+		command = strings.ToUpper(command)
+		if command != "USER" && command != "PASS" {
+			s.record(line)
+		}
+
+		switch command {
+		case "USER":
+			reply("331 password required")
+		case "PASS":
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 binary mode")
+		case "CWD":
+			if _, ok := s.dirs[strings.TrimSuffix(arg, "/")]; ok || arg == "/" {
+				reply("250 directory changed")
+			} else {
+				reply("550 not a directory")
+			}
+		case "SIZE":
+			if content, ok := s.files[arg]; ok {
+				reply("213 %d", len(content))
+			} else {
+				reply("550 no such file")
+			}
+		case "EPSV", "PASV":
+			if command == "EPSV" && s.noEPSV {
+				reply("500 EPSV not understood")
+				continue
+			}
+			passive, err = net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				reply("425 cannot open data port")
+				continue
+			}
+			port := passive.Addr().(*net.TCPAddr).Port
+			if command == "EPSV" {
+				reply("229 Entering Extended Passive Mode (|||%d|)", port)
+			} else {
+				reply("227 Entering Passive Mode (127,0,0,1,%d,%d)", port>>8, port&0xff)
+			}
+		case "PORT":
+			fields := strings.Split(arg, ",")
+			high, _ := strconv.Atoi(fields[4])
+			low, _ := strconv.Atoi(fields[5])
+			activeAddr = net.JoinHostPort(strings.Join(fields[:4], "."), strconv.Itoa(high<<8|low))
+			reply("200 PORT command successful")
+		case "REST":
+			offset, _ = strconv.ParseInt(arg, 10, 64)
+			reply("350 restarting at %d", offset)
+		case "RETR":
+			content, ok := s.files[arg]
+			if !ok {
+				reply("550 no such file")
+				continue
+			}
+			reply("150 opening data connection")
+			data, err := openData()
+			if err != nil {
+				reply("425 cannot open data connection")
+				continue
+			}
+			body := content[offset:]
+			offset = 0
+
+			s.mu.Lock()
+			cut := s.cutFirst > 0 && !s.cut
+			s.cut = s.cut || cut
+			s.mu.Unlock()
+			if cut {
+				data.Write([]byte(body[:s.cutFirst]))
+				data.Close()
+				reply("426 connection closed; transfer aborted")
+				continue
+			}
+			data.Write([]byte(body))
+			data.Close()
+			reply("226 transfer complete")
+		case "MLSD", "LIST":
+			listing, ok := s.dirs[strings.TrimSuffix(arg, "/")]
+			if command == "MLSD" {
+				if s.mlsd == nil {
+					reply("500 MLSD not understood")
+					continue
+				}
+				listing, ok = s.mlsd[strings.TrimSuffix(arg, "/")]
+			}
+			if !ok {
+				reply("550 no such directory")
+				continue
+			}
+			reply("150 here comes the listing")
+			data, err := openData()
+			if err != nil {
+				reply("425 cannot open data connection")
+				continue
+			}
+			data.Write([]byte(listing))
+			data.Close()
+			reply("226 listing sent")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// testBodyLimiter ограничивает все ответы одним размером
+type testBodyLimiter int64
+
+func (l testBodyLimiter) Limit(string) int64 {
+	return int64(l)
+}
+
+func newTestFTPClient(active bool, limiter httpserver.BodyLimiter) *FTPClient {
+	cfg := config.Default()
+	cfg.Timeout = 5 * time.Second
+	cfg.FTPActive = active
+	return NewFTP(cfg, nil, nil, limiter)
+}
+
+func TestFTPRetrievePassiveAndActive(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		active bool
+		noEPSV bool
+		mode   string
+	}{
+		{name: "EPSV", mode: "EPSV"},
+		{name: "PASV fallback", noEPSV: true, mode: "PASV"},
+		{name: "active", active: true, mode: "PORT"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &testFTPServer{
+				files:  map[string]string{"/pub/file.txt": "hello over ftp"},
+				dirs:   map[string]string{"/pub": ""},
+				noEPSV: tc.noEPSV,
+			}
+			addr := startTestFTPServer(t, server)
+
+			resp, err := newTestFTPClient(tc.active, nil).Get(context.Background(), "ftp://"+addr+"/pub/file.txt")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if string(resp.Body) != "hello over ftp" {
+				t.Fatalf("content = %q", resp.Body)
+			}
+			if len(server.received(tc.mode)) == 0 {
+				t.Fatalf("server did not receive %s, got %v", tc.mode, server.received(""))
+			}
+		})
+	}
+}
+
+func TestFTPListingMLSDAndLISTFallback(t *testing.T) {
+	unixListing := "drwxr-xr-x 2 owner group 4096 Jan 01 12:00 docs\r\n" +
+		"-rw-r--r-- 1 owner group 42 Jan 01 12:00 read me.txt\r\n"
+	mlsdListing := "type=cdir; .\r\ntype=dir;modify=20240101000000; docs\r\ntype=file;size=42; read me.txt\r\n"
+
+	for _, tc := range []struct {
+		name    string
+		mlsd    map[string]string
+		command string
+	}{
+		{name: "MLSD", mlsd: map[string]string{"/pub": mlsdListing}, command: "MLSD"},
+		{name: "LIST fallback", command: "LIST"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &testFTPServer{
+				dirs: map[string]string{"/pub": unixListing},
+				mlsd: tc.mlsd,
+			}
+			addr := startTestFTPServer(t, server)
+
+			resp, err := newTestFTPClient(false, nil).Get(context.Background(), "ftp://"+addr+"/pub/")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			listing := string(resp.Body)
+			for _, want := range []string{`href="/pub/docs/"`, `href="/pub/read%20me.txt"`} {
+				if !strings.Contains(listing, want) {
+					t.Errorf("listing has no %s:\n%s", want, listing)
+				}
+			}
+			if len(server.received(tc.command)) == 0 {
+				t.Fatalf("server did not receive %s, got %v", tc.command, server.received(""))
+			}
+		})
+	}
+}
+
+func TestFTPResumesInterruptedTransfer(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+	server := &testFTPServer{
+		files:    map[string]string{"/big.bin": content},
+		cutFirst: 30,
+	}
+	addr := startTestFTPServer(t, server)
+
+	resp, err := newTestFTPClient(false, nil).Get(context.Background(), "ftp://"+addr+"/big.bin")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(resp.Body) != content {
+		t.Fatalf("content = %q, want %q", resp.Body, content)
+	}
+	if rest := server.received("REST"); len(rest) != 1 || rest[0] != "REST 30" {
+		t.Fatalf("REST commands = %v, want [REST 30]", rest)
+	}
+}
+
+func TestFTPRespectsSizeLimit(t *testing.T) {
+	server := &testFTPServer{
+		files: map[string]string{"/big.bin": strings.Repeat("x", 100)},
+	}
+	addr := startTestFTPServer(t, server)
+
+	_, err := newTestFTPClient(false, testBodyLimiter(10)).Get(context.Background(), "ftp://"+addr+"/big.bin")
+	if !errors.Is(err, httpserver.ErrTooLarge) {
+		t.Fatalf("Get error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestParseLIST(t *testing.T) {
+	lines := []string{
+		"drwxr-xr-x 2 owner group 4096 Jan 01 12:00 docs",
+		"-rw-r--r--\t1 owner group 42 Jan 01 12:00 read me.txt",
+		"lrwxrwxrwx 1 owner group 7 Jan 01 12:00 latest -> v2.0",
+		"drwxr-xr-x 2 owner group 4096 Jan 01 12:00 .",
+		"01-01-24  10:00AM       <DIR>          Program Files",
+		"01-01-24\t10:00AM\t<DIR>\ttabbed",
+		"01-01-24  10:00AM                 1024 setup.exe",
+		"01-01-24",
+		"total 12",
+	}
+	want := []ftpEntry{
+		{Name: "docs", Dir: true},
+		{Name: "read me.txt"},
+		{Name: "latest"},
+		{Name: "Program Files", Dir: true},
+		{Name: "tabbed", Dir: true},
+		{Name: "setup.exe"},
+	}
+
+	got := parseLIST(lines)
+	if len(got) != len(want) {
+		t.Fatalf("parseLIST = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	httpserver "wget-go/internal/delivery/http-server"
+	"wget-go/internal/delivery/http-server/har"
+)
+
+// ErrNotRecorded возвращается в строгом режиме для URL, которого нет в архиве
+var ErrNotRecorded = errors.New("not recorded in replay archive")
+
+// maxReplayRedirects совпадает с лимитом редиректов HTTPClient
+const maxReplayRedirects = 10
+
+// recordedResponse ответ, сохраненный в архиве
+type recordedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Archive записанные ответы, проиндексированные по методу и URL
+type Archive struct {
+	responses map[string]*recordedResponse
+}
+
+// LoadArchive загружает архив HAR или WARC (в том числе .warc.gz)
+func LoadArchive(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open replay archive: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(5)
+
+	archive := &Archive{responses: make(map[string]*recordedResponse)}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}), string(magic) == "WARC/":
+		err = archive.loadWARC(reader)
+	default:
+		err = archive.loadHAR(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load replay archive %s: %w", path, err)
+	}
+	return archive, nil
+}
+
+// Len возвращает количество записанных ответов
+func (a *Archive) Len() int {
+	return len(a.responses)
+}
+
+// add сохраняет ответ; при повторах побеждает последняя запись
+func (a *Archive) add(method, rawURL string, resp *recordedResponse) {
+	a.responses[archiveKey(method, rawURL)] = resp
+}
+
+// lookup ищет ответ на запрос. HEAD отвечается записанным GET без тела
+func (a *Archive) lookup(method, rawURL string) (*recordedResponse, bool) {
+	if resp, exists := a.responses[archiveKey(method, rawURL)]; exists {
+		return resp, true
+	}
+	if method == http.MethodHead {
+		if resp, exists := a.responses[archiveKey(http.MethodGet, rawURL)]; exists {
+			return &recordedResponse{StatusCode: resp.StatusCode, Header: resp.Header}, true
+		}
+	}
+	return nil, false
+}
+
+// archiveKey ключ индекса: метод и URL без фрагмента
+func archiveKey(method, rawURL string) string {
+	if i := strings.IndexByte(rawURL, '#'); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	return method + " " + rawURL
+}
+
+// loadHAR читает записи HAR 1.2
+func (a *Archive) loadHAR(r io.Reader) error {
+	var doc har.Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("parse HAR: %w", err)
+	}
+
+	for _, entry := range doc.Log.Entries {
+		// Неудачные обмены не содержат ответа
+		if entry.Response.Status == 0 {
+			continue
+		}
+
+		header := make(http.Header)
+		for _, h := range entry.Response.Headers {
+			header.Add(h.Name, h.Value)
+		}
+
+		body := []byte(entry.Response.Content.Text)
+		if entry.Response.Content.Encoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Response.Content.Text)
+			if err != nil {
+				return fmt.Errorf("decode body of %s: %w", entry.Request.URL, err)
+			}
+			body = decoded
+		}
+
+		a.add(entry.Request.Method, entry.Request.URL, &recordedResponse{
+			StatusCode: entry.Response.Status,
+			Header:     header,
+			Body:       body,
+		})
+	}
+	return nil
+}
+
+// ReplayClient отвечает на запросы из архива, не обращаясь к сети
+type ReplayClient struct {
+	archive       *Archive
+	strict        bool
+	bodyLimiter   httpserver.BodyLimiter
+	robotsChecker httpserver.RobotsChecker
+
+	mu     sync.Mutex
+	misses map[string]bool
+}
+
+// NewReplay создает клиент воспроизведения. В строгом режиме неизвестный
+// URL является ошибкой, иначе на него отвечается 404
+func NewReplay(
+	archive *Archive,
+	strict bool,
+	bodyLimiter httpserver.BodyLimiter,
+	robotsChecker httpserver.RobotsChecker,
+) *ReplayClient {
+	return &ReplayClient{
+		archive:       archive,
+		strict:        strict,
+		bodyLimiter:   bodyLimiter,
+		robotsChecker: robotsChecker,
+		misses:        make(map[string]bool),
+	}
+}
+
+// Get возвращает записанный ответ на GET запрос
+func (c *ReplayClient) Get(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	return c.replay(ctx, http.MethodGet, rawURL)
+}
+
+// Head возвращает записанный ответ на HEAD запрос
+func (c *ReplayClient) Head(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	return c.replay(ctx, http.MethodHead, rawURL)
+}
+
+// Misses возвращает URL, которых не оказалось в архиве
+func (c *ReplayClient) Misses() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	misses := make([]string, 0, len(c.misses))
+	for rawURL := range c.misses {
+		misses = append(misses, rawURL)
+	}
+	sort.Strings(misses)
+	return misses
+}
+
+// replay находит ответ, проходя записанную цепочку редиректов
+func (c *ReplayClient) replay(ctx context.Context, method, rawURL string) (*httpserver.Response, error) {
+	if c.robotsChecker != nil && !c.robotsChecker.IsAllowed(rawURL) {
+		return nil, fmt.Errorf("access denied by robots.txt")
+	}
+
+	current := rawURL
+	for redirects := 0; ; redirects++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		recorded, found := c.archive.lookup(method, current)
+		if !found {
+			return nil, c.miss(current)
+		}
+
+		location := recorded.Header.Get("Location")
+		if !isRedirect(recorded.StatusCode) || location == "" {
+			return c.response(current, recorded)
+		}
+
+		if redirects >= maxReplayRedirects {
+			return nil, fmt.Errorf("stopped after %d redirects", maxReplayRedirects)
+		}
+		next, err := resolveLocation(current, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect location %q: %w", location, err)
+		}
+		current = next
+	}
+}
+
+// response преобразует записанный ответ так же, как это делает HTTPClient
+func (c *ReplayClient) response(rawURL string, recorded *recordedResponse) (*httpserver.Response, error) {
+	if recorded.StatusCode != http.StatusOK {
+		return nil, statusError(recorded.StatusCode)
+	}
+
+	if c.bodyLimiter != nil {
+		limit := c.bodyLimiter.Limit(recorded.Header.Get("Content-Type"))
+		if limit > 0 && int64(len(recorded.Body)) > limit {
+			return nil, fmt.Errorf("%w: more than %d bytes", httpserver.ErrTooLarge, limit)
+		}
+	}
+
+	return &httpserver.Response{
+		URL:         rawURL,
+		StatusCode:  recorded.StatusCode,
+		ContentType: recorded.Header.Get("Content-Type"),
+		Header:      recorded.Header.Clone(),
+		Body:        recorded.Body,
+	}, nil
+}
+
+// miss запоминает отсутствующий URL и возвращает ошибку согласно режиму
+func (c *ReplayClient) miss(rawURL string) error {
+	c.mu.Lock()
+	c.misses[rawURL] = true
+	c.mu.Unlock()
+
+	if c.strict {
+		return fmt.Errorf("%w: %s", ErrNotRecorded, rawURL)
+	}
+	return statusError(http.StatusNotFound)
+}
+
+// statusError возвращает ту же ошибку, что HTTPClient для записанного кода ответа
+func statusError(statusCode int) error {
+	return &httpserver.StatusError{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+	}
+}
+
+// isRedirect сообщает, является ли код ответа редиректом
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// resolveLocation разрешает заголовок Location относительно текущего URL
+func resolveLocation(base, location string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	target, err := baseURL.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return target.String(), nil
+}
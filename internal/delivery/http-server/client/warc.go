@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// loadWARC читает записи response из WARC архива. Сжатый архив
+// представляет собой последовательность gzip членов, которую
+// gzip.Reader читает как единый поток
+func (a *Archive) loadWARC(r *bufio.Reader) error {
+	if magic, _ := r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("open gzip: %w", err)
+		}
+		defer gz.Close()
+		r = bufio.NewReader(gz)
+	}
+
+	reader := textproto.NewReader(r)
+	for {
+		version, err := reader.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read record: %w", err)
+		}
+		// Пустые строки разделяют записи
+		if version == "" {
+			continue
+		}
+		if !strings.HasPrefix(version, "WARC/") {
+			return fmt.Errorf("unexpected record start %q", version)
+		}
+
+		header, err := reader.ReadMIMEHeader()
+		if err != nil {
+			return fmt.Errorf("read record header: %w", err)
+		}
+
+		length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil || length < 0 {
+			return fmt.Errorf("invalid record Content-Length %q", header.Get("Content-Length"))
+		}
+
+		block := make([]byte, length)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return fmt.Errorf("read record block: %w", err)
+		}
+
+		if err := a.addWARCRecord(header, block); err != nil {
+			return err
+		}
+	}
+}
+
+// addWARCRecord добавляет в архив HTTP ответ из записи типа response
+func (a *Archive) addWARCRecord(header textproto.MIMEHeader, block []byte) error {
+	if header.Get("WARC-Type") != "response" {
+		return nil
+	}
+
+	target := strings.Trim(header.Get("WARC-Target-URI"), "<>")
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		return fmt.Errorf("parse response for %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body for %s: %w", target, err)
+	}
+
+	a.add(http.MethodGet, target, &recordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	})
+	return nil
+}
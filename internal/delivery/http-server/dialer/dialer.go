@@ -0,0 +1,148 @@
+package dialer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Family определяет допустимое семейство IP адресов
+type Family int
+
+const (
+	FamilyAny Family = iota
+	FamilyIPv4
+	FamilyIPv6
+)
+
+// Dialer устанавливает соединения, самостоятельно разрешая имена:
+// учитывает переопределения -resolve, кэш DNS, семейство адресов
+// и проверяет адреса назначения защитой от SSRF
+type Dialer struct {
+	resolver  Resolver
+	overrides map[string][]net.IP // host:port -> адреса
+	family    Family
+	guard     *Guard // nil - без проверки адресов
+	netDialer *net.Dialer
+}
+
+// New создает диалер с указанным резолвером
+func New(resolver Resolver, overrides map[string][]net.IP, family Family, guard *Guard, timeout time.Duration) *Dialer {
+	return &Dialer{
+		resolver:  resolver,
+		overrides: overrides,
+		family:    family,
+		guard:     guard,
+		netDialer: &net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+}
+
+// ParseOverrides разбирает записи в формате curl: host:port:addr[,addr...]
+func ParseOverrides(entries []string) (map[string][]net.IP, error) {
+	overrides := make(map[string][]net.IP, len(entries))
+
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid resolve entry %q: expected host:port:addr", entry)
+		}
+
+		var ips []net.IP
+		for _, rawAddr := range strings.Split(parts[2], ",") {
+			rawAddr = strings.Trim(strings.TrimSpace(rawAddr), "[]")
+			ip := net.ParseIP(rawAddr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid resolve entry %q: bad address %q", entry, rawAddr)
+			}
+			ips = append(ips, ip)
+		}
+
+		key := net.JoinHostPort(strings.ToLower(parts[0]), parts[1])
+		overrides[key] = append(overrides[key], ips...)
+	}
+
+	return overrides, nil
+}
+
+// DialContext разрешает адрес и подключается к первому доступному IP
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := d.lookup(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, ip := range ips {
+		// Проверяем уже разрешенный адрес, к которому действительно подключаемся
+		if d.guard != nil {
+			if err := d.guard.Check(ip); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		conn, err := d.netDialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// lookup возвращает адреса хоста с учетом переопределений и семейства
+func (d *Dialer) lookup(ctx context.Context, host, port string) ([]net.IP, error) {
+	var candidates []net.IP
+
+	if ips, exists := d.overrides[net.JoinHostPort(strings.ToLower(host), port)]; exists {
+		candidates = ips
+	} else if ip := net.ParseIP(host); ip != nil {
+		candidates = []net.IP{ip}
+	} else {
+		addrs, err := d.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", host, err)
+		}
+		for _, addr := range addrs {
+			candidates = append(candidates, addr.IP)
+		}
+	}
+
+	ips := d.filterFamily(candidates)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("resolve %s: no addresses of the requested family", host)
+	}
+	return ips, nil
+}
+
+// filterFamily оставляет только адреса допустимого семейства
+func (d *Dialer) filterFamily(ips []net.IP) []net.IP {
+	if d.family == FamilyAny {
+		return ips
+	}
+
+	filtered := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		isIPv4 := ip.To4() != nil
+		if (d.family == FamilyIPv4) == isIPv4 {
+			filtered = append(filtered, ip)
+		}
+	}
+	return filtered
+}
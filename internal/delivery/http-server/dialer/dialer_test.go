@@ -0,0 +1,109 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseOverrides(t *testing.T) {
+	overrides, err := ParseOverrides([]string{
+		"Example.com:443:93.184.216.34",
+		"example.com:443:[2606:2800::1], 93.184.216.35",
+		"api.example.com:8080:10.0.0.5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]net.IP{
+		"example.com:443": {
+			net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800::1"), net.ParseIP("93.184.216.35"),
+		},
+		"api.example.com:8080": {net.ParseIP("10.0.0.5")},
+	}
+	if !reflect.DeepEqual(overrides, want) {
+		t.Fatalf("ParseOverrides = %v, want %v", overrides, want)
+	}
+
+	for _, entry := range []string{"example.com:443", "example.com::1.2.3.4", ":443:1.2.3.4", "example.com:443:not-an-ip"} {
+		if _, err := ParseOverrides([]string{entry}); err == nil {
+			t.Errorf("ParseOverrides(%q) accepted an invalid entry", entry)
+		}
+	}
+}
+
+func TestLookupOverridesAndFamily(t *testing.T) {
+	resolver := staticResolver{"93.184.216.34", "2606:2800::1"}
+	overrides := map[string][]net.IP{"example.com:8443": {net.ParseIP("10.0.0.7")}}
+
+	for _, tc := range []struct {
+		name   string
+		host   string
+		port   string
+		family Family
+		want   []string
+	}{
+		{name: "resolver", host: "example.com", port: "443", want: []string{"93.184.216.34", "2606:2800::1"}},
+		{name: "-resolve for host and port", host: "EXAMPLE.com", port: "8443", want: []string{"10.0.0.7"}},
+		{name: "literal IP", host: "192.0.2.1", port: "80", want: []string{"192.0.2.1"}},
+		{name: "IPv4 only", host: "example.com", port: "443", family: FamilyIPv4, want: []string{"93.184.216.34"}},
+		{name: "IPv6 only", host: "example.com", port: "443", family: FamilyIPv6, want: []string{"2606:2800::1"}},
+		{name: "no address of family", host: "example.com", port: "8443", family: FamilyIPv6},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := New(resolver, overrides, tc.family, nil, time.Second)
+			ips, err := d.lookup(context.Background(), tc.host, tc.port)
+			if tc.want == nil {
+				if err == nil {
+					t.Fatalf("lookup = %v, want an error", ips)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, ip := range ips {
+				got = append(got, ip.String())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("lookup = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// countingResolver считает обращения к вложенному резолверу
+type countingResolver struct {
+	calls int32
+}
+
+func (r *countingResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	atomic.AddInt32(&r.calls, 1)
+	return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+}
+
+func TestCachingResolverTTL(t *testing.T) {
+	next := &countingResolver{}
+	resolver := NewCaching(next, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.LookupIPAddr(context.Background(), "example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := atomic.LoadInt32(&next.calls); calls != 1 {
+		t.Fatalf("resolved %d times within TTL, want 1", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	resolver.LookupIPAddr(context.Background(), "example.com")
+	if calls := atomic.LoadInt32(&next.calls); calls != 2 {
+		t.Fatalf("resolved %d times after TTL, want 2", calls)
+	}
+}
@@ -0,0 +1,96 @@
+package dialer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrBlockedDestination возвращается при попытке подключиться к запрещенному адресу
+var ErrBlockedDestination = errors.New("destination address is blocked")
+
+// blockedNetworks диапазоны, недоступные при включенной защите помимо
+// loopback, private и link-local, которые проверяются методами net.IP
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",          // "Этот" хост
+	"100.64.0.0/10",      // Shared address space (CGNAT, метаданные Alibaba Cloud)
+	"192.0.0.0/24",       // IETF protocol assignments
+	"198.18.0.0/15",      // Бенчмаркинг
+	"240.0.0.0/4",        // Зарезервировано
+	"255.255.255.255/32", // Broadcast
+	"64:ff9b::/96",       // NAT64 может вести во внутреннюю сеть
+)
+
+// Guard проверяет адреса назначения после разрешения имени, поэтому
+// защищает и от редиректов, и от DNS rebinding
+type Guard struct {
+	allowed []*net.IPNet
+}
+
+// NewGuard создает защиту с исключениями из списка CIDR
+func NewGuard(allowCIDRs []string) (*Guard, error) {
+	allowed := make([]*net.IPNet, 0, len(allowCIDRs))
+	for _, cidr := range allowCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed cidr %q: %w", cidr, err)
+		}
+		allowed = append(allowed, network)
+	}
+	return &Guard{allowed: allowed}, nil
+}
+
+// Check возвращает ошибку, если подключение к ip запрещено
+func (g *Guard) Check(ip net.IP) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, network := range g.allowed {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+
+	if reason := blockReason(ip); reason != "" {
+		return fmt.Errorf("%w: %s is %s", ErrBlockedDestination, ip, reason)
+	}
+	return nil
+}
+
+// blockReason возвращает описание категории запрещенного адреса
+func blockReason(ip net.IP) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback"
+	case ip.IsPrivate():
+		return "private"
+	case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		// Сюда попадает и адрес метаданных облаков 169.254.169.254
+		return "link-local"
+	case ip.IsUnspecified():
+		return "unspecified"
+	case ip.IsMulticast():
+		return "multicast"
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return "reserved"
+		}
+	}
+	return ""
+}
+
+// mustParseCIDRs разбирает список CIDR, паникуя при ошибке
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,108 @@
+package dialer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGuardCheck(t *testing.T) {
+	guard, err := NewGuard([]string{"10.1.0.0/16", "fd00:1::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		ip     string
+		reason string // Пусто - адрес разрешен
+	}{
+		{ip: "93.184.216.34"},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946"},
+		{ip: "127.0.0.1", reason: "loopback"},
+		{ip: "127.10.0.5", reason: "loopback"},
+		{ip: "::1", reason: "loopback"},
+		{ip: "::ffff:127.0.0.1", reason: "loopback"},
+		{ip: "10.0.0.1", reason: "private"},
+		{ip: "172.16.5.4", reason: "private"},
+		{ip: "192.168.1.1", reason: "private"},
+		{ip: "fd12:3456::1", reason: "private"},
+		{ip: "169.254.169.254", reason: "link-local"},
+		{ip: "fe80::1", reason: "link-local"},
+		{ip: "0.0.0.0", reason: "unspecified"},
+		{ip: "::", reason: "unspecified"},
+		{ip: "224.0.0.1", reason: "link-local"},
+		{ip: "239.1.2.3", reason: "multicast"},
+		{ip: "100.64.0.1", reason: "reserved"},
+		{ip: "198.18.0.1", reason: "reserved"},
+		{ip: "240.0.0.1", reason: "reserved"},
+		{ip: "255.255.255.255", reason: "reserved"},
+		{ip: "64:ff9b::7f00:1", reason: "reserved"},
+		// Исключения -allow-cidr
+		{ip: "10.1.2.3"},
+		{ip: "fd00:1::5"},
+	} {
+		err := guard.Check(net.ParseIP(tc.ip))
+		switch {
+		case tc.reason == "" && err != nil:
+			t.Errorf("Check(%s) = %v, want allowed", tc.ip, err)
+		case tc.reason != "" && !errors.Is(err, ErrBlockedDestination):
+			t.Errorf("Check(%s) = %v, want blocked as %s", tc.ip, err, tc.reason)
+		case tc.reason != "" && !strings.HasSuffix(err.Error(), " is "+tc.reason):
+			t.Errorf("Check(%s) = %v, want reason %s", tc.ip, err, tc.reason)
+		}
+	}
+}
+
+func TestNewGuardRejectsInvalidCIDR(t *testing.T) {
+	if _, err := NewGuard([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("NewGuard accepted an invalid CIDR")
+	}
+}
+
+// staticResolver отвечает на любой запрос одним набором адресов
+type staticResolver []string
+
+func (r staticResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	addrs := make([]net.IPAddr, len(r))
+	for i, ip := range r {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+// TestGuardBlocksResolvedAddress проверяет адрес после разрешения имени:
+// публичное имя, указывающее на loopback (DNS rebinding), не пропускается
+func TestGuardBlocksResolvedAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	guard, _ := NewGuard(nil)
+	blocked := New(staticResolver{"127.0.0.1"}, nil, FamilyAny, guard, time.Second)
+	if _, err := blocked.DialContext(context.Background(), "tcp", net.JoinHostPort("rebind.example.com", port)); !errors.Is(err, ErrBlockedDestination) {
+		t.Fatalf("dial to a name resolving to loopback: %v, want ErrBlockedDestination", err)
+	}
+
+	allowing, _ := NewGuard([]string{"127.0.0.0/8"})
+	allowed := New(staticResolver{"127.0.0.1"}, nil, FamilyAny, allowing, time.Second)
+	conn, err := allowed.DialContext(context.Background(), "tcp", net.JoinHostPort("rebind.example.com", port))
+	if err != nil {
+		t.Fatalf("dial with 127.0.0.0/8 allowed: %v", err)
+	}
+	conn.Close()
+}
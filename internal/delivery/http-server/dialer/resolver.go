@@ -0,0 +1,62 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Resolver преобразует имя хоста в IP адреса.
+// Стандартный *net.Resolver удовлетворяет этому интерфейсу.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// cacheEntry закэшированный результат разрешения имени
+type cacheEntry struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// CachingResolver кэширует ответы вложенного резолвера на время TTL
+type CachingResolver struct {
+	next Resolver
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCaching создает кэширующий резолвер поверх next
+func NewCaching(next Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// LookupIPAddr возвращает адреса из кэша или запрашивает их у вложенного резолвера
+func (r *CachingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	now := time.Now()
+
+	r.mu.Lock()
+	entry, exists := r.entries[host]
+	r.mu.Unlock()
+
+	if exists && now.Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := r.next.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[host] = cacheEntry{addrs: addrs, expires: now.Add(r.ttl)}
+	r.mu.Unlock()
+
+	return addrs, nil
+}
@@ -0,0 +1,363 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// redactedValue значение, которым заменяются секреты
+const redactedValue = "[REDACTED]"
+
+// sensitiveHeaders заголовки, значения которых скрываются при редактировании
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// Recorder записывает HTTP обмены и сохраняет их в HAR файл
+type Recorder struct {
+	path     string
+	bodySize int64 // Сколько байт тела сохранять (0 - не сохранять)
+	redact   bool  // Скрывать авторизацию и cookies
+
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// NewRecorder создает запись в HAR файл path. Тела ответов сохраняются
+// до bodySize байт, а при redact скрываются авторизация и cookies
+func NewRecorder(path string, bodySize int64, redact bool) *Recorder {
+	return &Recorder{
+		path:     path,
+		bodySize: bodySize,
+		redact:   redact,
+	}
+}
+
+// Wrap возвращает транспорт, записывающий каждый обмен через next
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	return &transport{next: next, recorder: r}
+}
+
+// Close сохраняет записанные обмены в файл
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, *entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime < entries[j].StartedDateTime
+	})
+
+	doc := Document{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "wget-go", Version: "1.0"},
+		Entries: entries,
+	}}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode HAR: %w", err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create HAR directory: %w", err)
+		}
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write HAR: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// add регистрирует новую запись
+func (r *Recorder) add(entry *Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// update изменяет запись под блокировкой, чтобы Close не увидел ее наполовину
+func (r *Recorder) update(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+}
+
+// transport записывающий слой над http.RoundTripper
+type transport struct {
+	next     http.RoundTripper
+	recorder *Recorder
+}
+
+// RoundTrip выполняет запрос и записывает обмен. Каждый шаг редиректа
+// проходит через транспорт отдельно, поэтому цепочка видна целиком
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := newTrace()
+	req = req.WithContext(trace.withContext(req.Context()))
+
+	entry := &Entry{
+		StartedDateTime: trace.start.Format(time.RFC3339Nano),
+		Request:         t.recorder.request(req),
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	trace.responded(time.Now())
+
+	if err != nil {
+		entry.Response = Response{
+			Cookies:     []Cookie{},
+			Headers:     []NameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		entry.Error = err.Error()
+		entry.Timings = trace.timings(time.Now())
+		entry.Time = totalTime(entry.Timings)
+		t.recorder.add(entry)
+		return nil, err
+	}
+
+	entry.Response = t.recorder.response(resp)
+	entry.ServerIPAddress = trace.serverIP()
+	entry.Connection = trace.connection()
+	entry.Timings = trace.timings(time.Now())
+	entry.Time = totalTime(entry.Timings)
+	t.recorder.add(entry)
+
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		recorder:   t.recorder,
+		entry:      entry,
+		trace:      trace,
+		limit:      t.recorder.bodySize,
+	}
+	return resp, nil
+}
+
+// request описывает запрос в формате HAR
+func (r *Recorder) request(req *http.Request) Request {
+	headers := r.headers(req.Header)
+	headers = append([]NameValue{{Name: "Host", Value: req.Host}}, headers...)
+	if req.Host == "" {
+		headers[0].Value = req.URL.Host
+	}
+
+	query := []NameValue{}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			query = append(query, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(query, func(i, j int) bool { return query[i].Name < query[j].Name })
+
+	cookies := []Cookie{}
+	for _, cookie := range req.Cookies() {
+		cookies = append(cookies, Cookie{Name: cookie.Name, Value: r.secret(cookie.Value)})
+	}
+
+	return Request{
+		Method:      req.Method,
+		URL:         r.url(req),
+		HTTPVersion: req.Proto,
+		Cookies:     cookies,
+		Headers:     headers,
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+}
+
+// response описывает ответ в формате HAR без тела
+func (r *Recorder) response(resp *http.Response) Response {
+	cookies := []Cookie{}
+	for _, cookie := range resp.Cookies() {
+		har := Cookie{
+			Name:     cookie.Name,
+			Value:    r.secret(cookie.Value),
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			har.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		cookies = append(cookies, har)
+	}
+
+	redirectURL := ""
+	if location, err := resp.Location(); err == nil {
+		redirectURL = location.String()
+	}
+
+	return Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     cookies,
+		Headers:     r.headers(resp.Header),
+		Content: Content{
+			Size:     0,
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+		RedirectURL: redirectURL,
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// headers преобразует заголовки в отсортированный список, скрывая секреты
+func (r *Recorder) headers(header http.Header) []NameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := []NameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+				value = r.secret(value)
+			}
+			list = append(list, NameValue{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+// url возвращает адрес запроса, скрывая пароль из userinfo
+func (r *Recorder) url(req *http.Request) string {
+	if !r.redact || req.URL.User == nil {
+		return req.URL.String()
+	}
+	if _, hasPassword := req.URL.User.Password(); !hasPassword {
+		return req.URL.String()
+	}
+
+	u := *req.URL
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://"+req.URL.User.Username()+":"+redactedValue+"@", 1)
+}
+
+// secret возвращает значение или заглушку, если включено редактирование
+func (r *Recorder) secret(value string) string {
+	if r.redact {
+		return redactedValue
+	}
+	return value
+}
+
+// recordingBody считает прочитанное тело и сохраняет его начало в запись
+type recordingBody struct {
+	io.ReadCloser
+	recorder *Recorder
+	entry    *Entry
+	trace    *trace
+	limit    int64
+
+	size     int64
+	buf      bytes.Buffer
+	finished bool
+}
+
+// Read читает тело, копируя не более limit байт
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if remaining := b.limit - int64(b.buf.Len()); remaining > 0 && n > 0 {
+		chunk := p[:n]
+		if int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		b.buf.Write(chunk)
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close закрывает тело и завершает запись
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+// finish заполняет размер, тело и время получения
+func (b *recordingBody) finish() {
+	if b.finished {
+		return
+	}
+	b.finished = true
+
+	now := time.Now()
+	b.recorder.update(func() {
+		b.entry.Response.BodySize = b.size
+		b.entry.Response.Content.Size = b.size
+		b.entry.Timings = b.trace.timings(now)
+		b.entry.Time = totalTime(b.entry.Timings)
+
+		if b.buf.Len() == 0 {
+			return
+		}
+		content := &b.entry.Response.Content
+		if isText(content.MimeType) && utf8.Valid(b.buf.Bytes()) {
+			content.Text = b.buf.String()
+		} else {
+			content.Text = base64.StdEncoding.EncodeToString(b.buf.Bytes())
+			content.Encoding = "base64"
+		}
+		if int64(b.buf.Len()) < b.size {
+			content.Comment = fmt.Sprintf("truncated to %d of %d bytes", b.buf.Len(), b.size)
+		}
+	})
+}
+
+// isText сообщает, можно ли сохранить тело такого типа как текст
+func isText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/json" ||
+		mediaType == "application/xml" ||
+		mediaType == "application/javascript"
+}
+
+// totalTime суммирует применимые фазы запроса
+func totalTime(timings Timings) float64 {
+	total := 0.0
+	// ssl уже входит в connect
+	for _, phase := range []float64{timings.Blocked, timings.DNS, timings.Connect, timings.Send, timings.Wait, timings.Receive} {
+		if phase > 0 {
+			total += phase
+		}
+	}
+	return total
+}
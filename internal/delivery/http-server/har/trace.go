@@ -0,0 +1,172 @@
+package har
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// trace собирает моменты фаз одного запроса через httptrace
+type trace struct {
+	start time.Time
+
+	mu           sync.Mutex
+	gotConn      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	respondedAt  time.Time
+	remoteAddr   net.Addr
+	localAddr    net.Addr
+}
+
+// newTrace начинает отсчет фаз запроса
+func newTrace() *trace {
+	return &trace{start: time.Now()}
+}
+
+// withContext подключает трассировку к контексту запроса
+func (t *trace) withContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mark(&t.gotConn)
+			t.mu.Lock()
+			t.remoteAddr = info.Conn.RemoteAddr()
+			t.localAddr = info.Conn.LocalAddr()
+			t.mu.Unlock()
+		},
+		DNSStart:     func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart: func(string, string) { t.markOnce(&t.connectStart) },
+		ConnectDone:  func(string, string, error) { t.mark(&t.connectDone) },
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mark(&t.tlsDone)
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	})
+}
+
+// mark запоминает текущий момент
+func (t *trace) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*at = time.Now()
+}
+
+// markOnce запоминает только первый момент (при нескольких попытках соединения)
+func (t *trace) markOnce(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// responded отмечает получение заголовков ответа
+func (t *trace) responded(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.respondedAt = at
+}
+
+// serverIP возвращает адрес сервера, к которому шел запрос
+func (t *trace) serverIP() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if addr, ok := t.remoteAddr.(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return ""
+}
+
+// connection возвращает локальный порт соединения как его идентификатор
+func (t *trace) connection() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if addr, ok := t.localAddr.(*net.TCPAddr); ok {
+		return strconv.Itoa(addr.Port)
+	}
+	return ""
+}
+
+// timings переводит моменты фаз в длительности HAR, end - конец получения тела
+func (t *trace) timings(end time.Time) Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+
+	// Ответ не прошел через сеть (например, взят из кэша)
+	if t.gotConn.IsZero() {
+		responded := t.respondedAt
+		if responded.IsZero() {
+			responded = end
+		}
+		timings.Wait = millis(t.start, responded)
+		timings.Receive = millis(responded, end)
+		return timings
+	}
+
+	if !t.dnsStart.IsZero() && !t.dnsDone.IsZero() {
+		timings.DNS = millis(t.dnsStart, t.dnsDone)
+	}
+	if !t.connectStart.IsZero() {
+		connected := t.connectDone
+		if !t.tlsDone.IsZero() {
+			connected = t.tlsDone
+		}
+		if !connected.IsZero() {
+			timings.Connect = millis(t.connectStart, connected)
+		}
+	}
+	if !t.tlsStart.IsZero() && !t.tlsDone.IsZero() {
+		timings.SSL = millis(t.tlsStart, t.tlsDone)
+	}
+
+	blocked := millis(t.start, t.gotConn)
+	if timings.DNS > 0 {
+		blocked -= timings.DNS
+	}
+	if timings.Connect > 0 {
+		blocked -= timings.Connect
+	}
+	if blocked < 0 {
+		blocked = 0
+	}
+	timings.Blocked = blocked
+
+	wrote := t.wroteRequest
+	if wrote.IsZero() {
+		wrote = t.gotConn
+	}
+	firstByte := t.firstByte
+	if firstByte.IsZero() {
+		firstByte = wrote
+	}
+
+	timings.Send = millis(t.gotConn, wrote)
+	timings.Wait = millis(wrote, firstByte)
+	timings.Receive = millis(firstByte, end)
+	return timings
+}
+
+// millis возвращает неотрицательную длительность между моментами в миллисекундах
+func millis(from, to time.Time) float64 {
+	if to.Before(from) {
+		return 0
+	}
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
@@ -2,12 +2,59 @@ package httpserver
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
 )
 
+// ErrTooLarge возвращается, когда ответ превышает допустимый размер
+var ErrTooLarge = errors.New("response exceeds size limit")
+
+// ErrRangeNotSupported возвращается клиентами, не умеющими запрашивать диапазоны
+var ErrRangeNotSupported = errors.New("range requests not supported")
+
+// ErrRangeIgnored возвращается, когда сервер ответил на запрос диапазона целиком,
+// например из-за изменившегося валидатора If-Range
+var ErrRangeIgnored = errors.New("server ignored range request")
+
+// StatusError ответ сервера с кодом, отличным от ожидаемого
+type StatusError struct {
+	StatusCode int    // Код ответа
+	Status     string // Строка статуса, например "404 Not Found"
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Status)
+}
+
+// Response результат запроса к ресурсу
+type Response struct {
+	URL         string      // Итоговый URL после редиректов
+	StatusCode  int         // Код ответа
+	ContentType string      // Значение Content-Type
+	Header      http.Header // Заголовки ответа
+	Body        []byte      // Тело ответа (пустое для HEAD)
+}
+
 // Client определяет контракт HTTP клиента
 type Client interface {
-	Get(ctx context.Context, url string) ([]byte, string, error)
-	Head(ctx context.Context, url string) (string, error)
+	Get(ctx context.Context, url string) (*Response, error)
+	Head(ctx context.Context, url string) (*Response, error)
+}
+
+// RangeClient скачивает диапазон байт ресурса [start, end] в dst.
+// ifRange задает валидатор (ETag или Last-Modified), при изменении
+// которого сервер вернет ресурс целиком и запрос завершится ErrRangeIgnored
+type RangeClient interface {
+	GetRange(ctx context.Context, url string, start, end int64, ifRange string, dst io.Writer) (*Response, error)
+}
+
+// Dialer устанавливает сетевые соединения для транспорта клиента
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
 }
 
 // RateLimiter ограничивает частоту запросов
@@ -16,6 +63,29 @@ type RateLimiter interface {
 	SetRate(rate int)
 }
 
+// HostLimiter ограничивает частоту запросов независимо для каждого хоста
+type HostLimiter interface {
+	Wait(ctx context.Context, host string) error
+}
+
+// HostPacer задает минимальную паузу между запросами к хосту
+type HostPacer interface {
+	SlowDown(host string, wait time.Duration) bool
+}
+
+// TransportWrapper оборачивает транспорт HTTP клиента дополнительным слоем
+type TransportWrapper func(next http.RoundTripper) http.RoundTripper
+
+// BodyLimiter задает максимальный размер тела ответа по его Content-Type
+type BodyLimiter interface {
+	Limit(contentType string) int64
+}
+
+// RateController подстраивает скорость хоста по ответам сервера
+type RateController interface {
+	Observe(host string, statusCode int, latency time.Duration, err error)
+}
+
 // RobotsChecker проверяет robots.txt
 //
 // robots.txt — это текстовый файл, который веб-мастера размещают в
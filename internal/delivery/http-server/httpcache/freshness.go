@@ -0,0 +1,203 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heuristicFraction доля возраста документа, используемая как эвристический
+// срок свежести при наличии только Last-Modified (RFC 9111, 4.2.2)
+const heuristicFraction = 0.1
+
+// heuristicallyCacheable коды, которые можно кэшировать без явного срока (RFC 9110, 15.1)
+var heuristicallyCacheable = map[int]bool{
+	200: true, 203: true, 204: true, 206: true, 300: true, 301: true,
+	308: true, 404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// cacheControl разобранные директивы Cache-Control
+type cacheControl map[string]string
+
+// parseCacheControl разбирает заголовок Cache-Control
+func parseCacheControl(header http.Header) cacheControl {
+	directives := make(cacheControl)
+
+	for _, line := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(line, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			name, value, _ := strings.Cut(part, "=")
+			directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return directives
+}
+
+// has проверяет наличие директивы
+func (cc cacheControl) has(name string) bool {
+	_, exists := cc[name]
+	return exists
+}
+
+// seconds возвращает числовое значение директивы
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	value, exists := cc[name]
+	if !exists {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// storable проверяет, можно ли сохранить ответ в приватный кэш (RFC 9111, 3)
+func storable(req *http.Request, resp *http.Response) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+
+	// Кэш хранит только полные тела, часть ответа 206 нельзя отдать на
+	// обычный запрос (RFC 9111, 3.3)
+	if resp.StatusCode == http.StatusPartialContent {
+		return false
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	respCC := parseCacheControl(resp.Header)
+	if reqCC.has("no-store") || respCC.has("no-store") {
+		return false
+	}
+
+	// Vary: * означает, что ответ нельзя переиспользовать
+	if strings.TrimSpace(resp.Header.Get("Vary")) == "*" {
+		return false
+	}
+
+	// Без явной разрешающей директивы кэшируем только коды,
+	// допускающие эвристическую свежесть
+	if resp.Header.Get("Expires") != "" || respCC.has("max-age") || respCC.has("public") {
+		return true
+	}
+	return heuristicallyCacheable[resp.StatusCode]
+}
+
+// freshnessLifetime вычисляет срок свежести ответа (RFC 9111, 4.2.1)
+func freshnessLifetime(entry *Entry) time.Duration {
+	cc := parseCacheControl(entry.Header)
+
+	if maxAge, ok := cc.seconds("max-age"); ok {
+		return maxAge
+	}
+
+	date := responseDate(entry)
+	if expires := entry.Header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			// Некорректный Expires означает "уже устарел"
+			return 0
+		}
+		return expiresAt.Sub(date)
+	}
+
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" && heuristicallyCacheable[entry.StatusCode] {
+		if modifiedAt, err := http.ParseTime(lastModified); err == nil && modifiedAt.Before(date) {
+			return time.Duration(heuristicFraction * float64(date.Sub(modifiedAt)))
+		}
+	}
+
+	return 0
+}
+
+// currentAge вычисляет текущий возраст ответа (RFC 9111, 4.2.3)
+func currentAge(entry *Entry, now time.Time) time.Duration {
+	apparentAge := entry.ResponseTime.Sub(responseDate(entry))
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+
+	var ageValue time.Duration
+	if age, err := strconv.ParseInt(entry.Header.Get("Age"), 10, 64); err == nil && age > 0 {
+		ageValue = time.Duration(age) * time.Second
+	}
+
+	responseDelay := entry.ResponseTime.Sub(entry.RequestTime)
+	correctedAge := ageValue + responseDelay
+
+	initialAge := apparentAge
+	if correctedAge > initialAge {
+		initialAge = correctedAge
+	}
+
+	return initialAge + now.Sub(entry.ResponseTime)
+}
+
+// responseDate возвращает значение Date или время получения ответа
+func responseDate(entry *Entry) time.Time {
+	if date, err := http.ParseTime(entry.Header.Get("Date")); err == nil {
+		return date
+	}
+	return entry.ResponseTime
+}
+
+// isFresh проверяет, можно ли отдать запись без валидации
+func isFresh(req *http.Request, entry *Entry, now time.Time) bool {
+	reqCC := parseCacheControl(req.Header)
+	respCC := parseCacheControl(entry.Header)
+
+	if reqCC.has("no-cache") || respCC.has("no-cache") {
+		return false
+	}
+
+	lifetime := freshnessLifetime(entry)
+	if maxAge, ok := reqCC.seconds("max-age"); ok && maxAge < lifetime {
+		lifetime = maxAge
+	}
+
+	age := currentAge(entry, now)
+	if minFresh, ok := reqCC.seconds("min-fresh"); ok {
+		age += minFresh
+	}
+
+	return age < lifetime
+}
+
+// IsFresh проверяет свежесть записи для обычного запроса без директив
+func (e *Entry) IsFresh(now time.Time) bool {
+	return isFresh(&http.Request{Header: http.Header{}}, e, now)
+}
+
+// Age возвращает текущий возраст записи
+func (e *Entry) Age(now time.Time) time.Duration {
+	return currentAge(e, now)
+}
+
+// varyMatches проверяет, что запрос совпадает с сохраненным по заголовкам из Vary
+func varyMatches(req *http.Request, entry *Entry) bool {
+	for _, name := range varyHeaders(entry.Header) {
+		if req.Header.Get(name) != entry.RequestHeader.Get(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// varyHeaders возвращает имена заголовков из Vary
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, line := range header.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
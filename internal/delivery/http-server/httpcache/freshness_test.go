@@ -0,0 +1,216 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	received := now.Add(-10 * time.Minute)
+	httpDate := func(at time.Time) string { return at.Format(http.TimeFormat) }
+
+	for _, tc := range []struct {
+		name          string
+		status        int
+		header        http.Header
+		requestHeader http.Header
+		fresh         bool
+	}{
+		{
+			name:   "max-age not expired",
+			header: http.Header{"Cache-Control": {"max-age=3600"}, "Date": {httpDate(received)}},
+			fresh:  true,
+		},
+		{
+			name:   "max-age expired",
+			header: http.Header{"Cache-Control": {"max-age=300"}, "Date": {httpDate(received)}},
+		},
+		{
+			name:   "Age counts toward max-age",
+			header: http.Header{"Cache-Control": {"max-age=900"}, "Date": {httpDate(received)}, "Age": {"600"}},
+		},
+		{
+			name:   "max-age wins over Expires",
+			header: http.Header{"Cache-Control": {"max-age=3600"}, "Date": {httpDate(received)}, "Expires": {httpDate(received)}},
+			fresh:  true,
+		},
+		{
+			name:   "Expires in the future",
+			header: http.Header{"Date": {httpDate(received)}, "Expires": {httpDate(received.Add(time.Hour))}},
+			fresh:  true,
+		},
+		{
+			name:   "invalid Expires is stale",
+			header: http.Header{"Date": {httpDate(received)}, "Expires": {"0"}},
+		},
+		{
+			// 10% от возраста в 10 дней - сутки
+			name:   "heuristic from Last-Modified",
+			header: http.Header{"Date": {httpDate(received)}, "Last-Modified": {httpDate(received.AddDate(0, 0, -10))}},
+			fresh:  true,
+		},
+		{
+			name:   "no heuristic for uncacheable status",
+			status: http.StatusFound,
+			header: http.Header{"Date": {httpDate(received)}, "Last-Modified": {httpDate(received.AddDate(0, 0, -10))}},
+		},
+		{
+			name:   "no validators or lifetime",
+			header: http.Header{"Date": {httpDate(received)}},
+		},
+		{
+			name:   "response no-cache",
+			header: http.Header{"Cache-Control": {"max-age=3600, no-cache"}, "Date": {httpDate(received)}},
+		},
+		{
+			name:          "request no-cache",
+			header:        http.Header{"Cache-Control": {"max-age=3600"}, "Date": {httpDate(received)}},
+			requestHeader: http.Header{"Cache-Control": {"no-cache"}},
+		},
+		{
+			name:          "request max-age shortens lifetime",
+			header:        http.Header{"Cache-Control": {"max-age=3600"}, "Date": {httpDate(received)}},
+			requestHeader: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			name:          "request min-fresh",
+			header:        http.Header{"Cache-Control": {"max-age=900"}, "Date": {httpDate(received)}},
+			requestHeader: http.Header{"Cache-Control": {"min-fresh=600"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status := tc.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			entry := &Entry{
+				StatusCode:   status,
+				Header:       tc.header,
+				RequestTime:  received,
+				ResponseTime: received,
+			}
+			requestHeader := tc.requestHeader
+			if requestHeader == nil {
+				requestHeader = http.Header{}
+			}
+
+			if got := isFresh(&http.Request{Header: requestHeader}, entry, now); got != tc.fresh {
+				t.Fatalf("isFresh = %v, want %v (lifetime %v, age %v)", got, tc.fresh, freshnessLifetime(entry), currentAge(entry, now))
+			}
+		})
+	}
+}
+
+func TestStorable(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		method        string
+		status        int
+		header        http.Header
+		requestHeader http.Header
+		want          bool
+	}{
+		{name: "200 without directives", status: 200, want: true},
+		{name: "302 without directives", status: 302},
+		{name: "302 with max-age", status: 302, header: http.Header{"Cache-Control": {"max-age=60"}}, want: true},
+		{name: "response no-store", status: 200, header: http.Header{"Cache-Control": {"no-store"}}},
+		{name: "request no-store", status: 200, requestHeader: http.Header{"Cache-Control": {"no-store"}}},
+		{name: "Vary *", status: 200, header: http.Header{"Vary": {"*"}}},
+		{name: "HEAD", method: http.MethodHead, status: 200},
+		{name: "206", status: 206, header: http.Header{"Cache-Control": {"max-age=60"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "http://example.com/", nil)
+			for name, values := range tc.requestHeader {
+				req.Header[name] = values
+			}
+			header := tc.header
+			if header == nil {
+				header = http.Header{}
+			}
+
+			if got := storable(req, &http.Response{StatusCode: tc.status, Header: header}); got != tc.want {
+				t.Fatalf("storable = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRevalidation(t *testing.T) {
+	var requests, conditional int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=0")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&conditional, 1)
+			w.Header().Set("X-Updated", "yes")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("original body"))
+	}))
+	defer server.Close()
+
+	transport := newTestTransport(t, 0)
+	if _, body := get(t, transport, server.URL, ""); body != "original body" {
+		t.Fatalf("first GET body %q", body)
+	}
+
+	// Устаревшая запись проверяется условным запросом, тело берется из кэша
+	resp, body := get(t, transport, server.URL, "")
+	if body != "original body" || resp.Header.Get("X-Cache") != "REVALIDATED" {
+		t.Fatalf("second GET: body %q, X-Cache %q", body, resp.Header.Get("X-Cache"))
+	}
+	if resp.Header.Get("X-Updated") != "yes" {
+		t.Error("headers from 304 were not merged into the entry")
+	}
+	if atomic.LoadInt32(&requests) != 2 || atomic.LoadInt32(&conditional) != 1 {
+		t.Fatalf("server got %d requests, %d conditional; want 2 and 1", requests, conditional)
+	}
+}
+
+func TestOfflineMissAndHit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Write([]byte("cached"))
+	}))
+	defer server.Close()
+
+	online := newTestTransport(t, 0)
+	get(t, online, server.URL, "")
+	offline := NewTransport(failingTransport{t}, online.store, true, nil)
+
+	// Запись устарела, но без сети отдается как есть
+	if resp, body := get(t, offline, server.URL, ""); body != "cached" || resp.Header.Get("X-Cache") != "HIT" {
+		t.Fatalf("offline hit: body %q, X-Cache %q", body, resp.Header.Get("X-Cache"))
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	resp, err := offline.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("offline miss status %d, want 504", resp.StatusCode)
+	}
+}
+
+// failingTransport проваливает тест при любом обращении к сети
+type failingTransport struct {
+	t *testing.T
+}
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.t.Fatalf("offline cache went to the network for %s", req.URL)
+	return nil, nil
+}
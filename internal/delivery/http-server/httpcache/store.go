@@ -0,0 +1,321 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	metaSuffix = ".json"
+	bodySuffix = ".body"
+)
+
+// Entry метаданные закэшированного ответа
+type Entry struct {
+	URL           string      `json:"url"`
+	StatusCode    int         `json:"status_code"`
+	Header        http.Header `json:"header"`
+	RequestHeader http.Header `json:"request_header"` // Значения заголовков из Vary
+	RequestTime   time.Time   `json:"request_time"`
+	ResponseTime  time.Time   `json:"response_time"`
+	Size          int64       `json:"size"`
+}
+
+// indexItem сведения о записи для вытеснения
+type indexItem struct {
+	size       int64
+	lastAccess time.Time
+}
+
+// Store хранит ответы на диске: метаданные в JSON и тело в отдельном файле.
+// Время последнего доступа хранится в mtime файла тела
+type Store struct {
+	dir     string
+	maxSize int64 // 0 - без ограничения
+
+	mu    sync.Mutex
+	index map[string]indexItem
+	total int64
+}
+
+// Open открывает (или создает) кэш в каталоге dir
+func Open(dir string, maxSize int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+
+	store := &Store{
+		dir:     dir,
+		maxSize: maxSize,
+		index:   make(map[string]indexItem),
+	}
+
+	if err := store.loadIndex(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// loadIndex строит индекс по файлам тел в каталоге кэша
+func (s *Store) loadIndex() error {
+	return filepath.WalkDir(s.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, bodySuffix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		key := strings.TrimSuffix(filepath.Base(path), bodySuffix)
+		s.index[key] = indexItem{size: info.Size(), lastAccess: info.ModTime()}
+		s.total += info.Size()
+		return nil
+	})
+}
+
+// Key возвращает ключ записи для URL
+func Key(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// paths возвращает пути к файлам метаданных и тела
+func (s *Store) paths(key string) (string, string) {
+	base := filepath.Join(s.dir, key[:2], key)
+	return base + metaSuffix, base + bodySuffix
+}
+
+// Get загружает запись и тело по URL
+func (s *Store) Get(rawURL string) (*Entry, []byte, error) {
+	key := Key(rawURL)
+	metaPath, bodyPath := s.paths(key)
+
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil, fmt.Errorf("decode cache entry: %w", err)
+	}
+
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.touch(key, bodyPath)
+	return &entry, body, nil
+}
+
+// Put сохраняет запись и тело, вытесняя старые записи при превышении размера
+func (s *Store) Put(entry *Entry, body []byte) error {
+	key := Key(entry.URL)
+	metaPath, bodyPath := s.paths(key)
+
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	entry.Size = int64(len(body))
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+
+	if err := writeFileAtomic(bodyPath, body); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(metaPath, data); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.total += entry.Size - s.index[key].size
+	s.index[key] = indexItem{size: entry.Size, lastAccess: time.Now()}
+	s.mu.Unlock()
+
+	return s.evict(key)
+}
+
+// UpdateMeta перезаписывает только метаданные записи (после ответа 304)
+func (s *Store) UpdateMeta(entry *Entry) error {
+	metaPath, _ := s.paths(Key(entry.URL))
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	return writeFileAtomic(metaPath, data)
+}
+
+// Delete удаляет запись по URL
+func (s *Store) Delete(rawURL string) error {
+	return s.remove(Key(rawURL))
+}
+
+// Purge удаляет все записи
+func (s *Store) Purge() error {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	for _, key := range keys {
+		if err := s.remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List возвращает метаданные всех записей, отсортированные по URL
+func (s *Store) List() ([]*Entry, error) {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	entries := make([]*Entry, 0, len(keys))
+	for _, key := range keys {
+		metaPath, _ := s.paths(key)
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+	return entries, nil
+}
+
+// Size возвращает суммарный размер тел в кэше
+func (s *Store) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
+
+// touch обновляет время последнего доступа к записи
+func (s *Store) touch(key, bodyPath string) {
+	now := time.Now()
+	os.Chtimes(bodyPath, now, now)
+
+	s.mu.Lock()
+	if item, exists := s.index[key]; exists {
+		item.lastAccess = now
+		s.index[key] = item
+	}
+	s.mu.Unlock()
+}
+
+// evict удаляет давно не использованные записи, пока кэш превышает лимит.
+// Только что записанная запись keep не вытесняется
+func (s *Store) evict(keep string) error {
+	if s.maxSize <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.total <= s.maxSize {
+		s.mu.Unlock()
+		return nil
+	}
+
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		if key != keep {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.index[keys[i]].lastAccess.Before(s.index[keys[j]].lastAccess)
+	})
+
+	var victims []string
+	total := s.total
+	for _, key := range keys {
+		if total <= s.maxSize {
+			break
+		}
+		total -= s.index[key].size
+		victims = append(victims, key)
+	}
+	s.mu.Unlock()
+
+	for _, key := range victims {
+		if err := s.remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove удаляет файлы записи и обновляет индекс
+func (s *Store) remove(key string) error {
+	metaPath, bodyPath := s.paths(key)
+
+	for _, path := range []string{metaPath, bodyPath} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove cache entry: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.total -= s.index[key].size
+	delete(s.index, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// writeFileAtomic записывает файл через временный файл и переименование
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write cache file: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package httpcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+// hopByHopHeaders заголовки, которые не переносятся из ответа 304 в запись
+var hopByHopHeaders = map[string]bool{
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Content-Length":    true,
+	"Content-Encoding":  true,
+}
+
+// Transport кэширующий слой над http.RoundTripper согласно RFC 9111
+type Transport struct {
+	next        http.RoundTripper
+	store       *Store
+	offline     bool
+	bodyLimiter httpserver.BodyLimiter // Лимиты размера клиента (-max-file-size, -max-size-by-type)
+}
+
+// NewTransport создает кэширующий транспорт.
+// В режиме offline запросы не уходят в сеть, а промах возвращает 504.
+// Ответы больше лимита bodyLimiter не сохраняются и передаются клиенту
+// потоком, чтобы он сам применил лимит
+func NewTransport(next http.RoundTripper, store *Store, offline bool, bodyLimiter httpserver.BodyLimiter) *Transport {
+	return &Transport{
+		next:        next,
+		store:       store,
+		offline:     offline,
+		bodyLimiter: bodyLimiter,
+	}
+}
+
+// RoundTrip отдает ответ из кэша, валидирует устаревшую запись или идет в сеть
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+	// Запросы частей (сегменты, докачка) идут мимо кэша: запись хранит
+	// полное тело под URL и не подходит для ответа на Range
+	if parseCacheControl(req.Header).has("no-store") || req.Header.Get("Range") != "" || req.Header.Get("If-Range") != "" {
+		return t.forward(req)
+	}
+
+	entry, body, err := t.store.Get(req.URL.String())
+	if err != nil || !varyMatches(req, entry) {
+		entry = nil
+	}
+
+	if entry == nil {
+		if t.offline {
+			return gatewayTimeout(req), nil
+		}
+		return t.fetch(req)
+	}
+
+	now := time.Now()
+	if t.offline || isFresh(req, entry, now) {
+		return cachedResponse(req, entry, body, now, "HIT"), nil
+	}
+
+	return t.revalidate(req, entry, body)
+}
+
+// fetch выполняет запрос и сохраняет ответ, если его можно кэшировать
+func (t *Transport) fetch(req *http.Request) (*http.Response, error) {
+	requestTime := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Method != http.MethodGet || !storable(req, resp) {
+		return resp, nil
+	}
+
+	body, ok, err := t.bufferBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		t.save(req, resp, body, requestTime)
+	}
+	return resp, nil
+}
+
+// bufferBody читает тело ответа для сохранения в кэш не больше лимита
+// размера. Тело больше лимита, а при заданном лимите и тело неизвестной
+// длины, не сохраняется: ответ остается потоком, и клиент прерывает его
+// по своему лимиту
+func (t *Transport) bufferBody(resp *http.Response) ([]byte, bool, error) {
+	var limit int64
+	if t.bodyLimiter != nil {
+		limit = t.bodyLimiter.Limit(resp.Header.Get("Content-Type"))
+	}
+	if limit > 0 && (resp.ContentLength < 0 || resp.ContentLength > limit) {
+		return nil, false, nil
+	}
+
+	reader := io.Reader(resp.Body)
+	if limit > 0 {
+		reader = io.LimitReader(resp.Body, limit+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, err
+	}
+
+	// Сервер прислал больше, чем заявил в Content-Length: прочитанное
+	// отдается вместе с остатком потока
+	if limit > 0 && int64(len(body)) > limit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil, false, nil
+	}
+
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true, nil
+}
+
+// revalidate отправляет условный запрос и при 304 отдает сохраненное тело
+func (t *Transport) revalidate(req *http.Request, entry *Entry, body []byte) (*http.Response, error) {
+	conditional := req.Clone(req.Context())
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := time.Now()
+	resp, err := t.next.RoundTrip(conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusNotModified {
+		if req.Method == http.MethodGet && storable(req, resp) {
+			fresh, ok, err := t.bufferBody(resp)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				t.save(req, resp, fresh, requestTime)
+			}
+		}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	// Обновляем сохраненные заголовки значениями из 304 (RFC 9111, 4.3.4)
+	for name, values := range resp.Header {
+		if !hopByHopHeaders[name] {
+			entry.Header[name] = values
+		}
+	}
+	entry.RequestTime = requestTime
+	entry.ResponseTime = time.Now()
+
+	if err := t.store.UpdateMeta(entry); err != nil {
+		log.Printf("HTTP cache: failed to update %s: %v", entry.URL, err)
+	}
+
+	return cachedResponse(req, entry, body, entry.ResponseTime, "REVALIDATED"), nil
+}
+
+// save сохраняет ответ в кэш
+func (t *Transport) save(req *http.Request, resp *http.Response, body []byte, requestTime time.Time) {
+	requestHeader := make(http.Header)
+	for _, name := range varyHeaders(resp.Header) {
+		if value := req.Header.Get(name); value != "" {
+			requestHeader.Set(name, value)
+		}
+	}
+
+	entry := &Entry{
+		URL:           req.URL.String(),
+		StatusCode:    resp.StatusCode,
+		Header:        resp.Header.Clone(),
+		RequestHeader: requestHeader,
+		RequestTime:   requestTime,
+		ResponseTime:  time.Now(),
+	}
+
+	if err := t.store.Put(entry, body); err != nil {
+		log.Printf("HTTP cache: failed to store %s: %v", entry.URL, err)
+	}
+}
+
+// forward передает запрос дальше без участия кэша
+func (t *Transport) forward(req *http.Request) (*http.Response, error) {
+	if t.offline {
+		return gatewayTimeout(req), nil
+	}
+	return t.next.RoundTrip(req)
+}
+
+// cachedResponse строит ответ из записи кэша
+func cachedResponse(req *http.Request, entry *Entry, body []byte, now time.Time, status string) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(currentAge(entry, now)/time.Second), 10))
+	header.Set("X-Cache", status)
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+
+	if req.Method == http.MethodHead {
+		resp.Body = http.NoBody
+	} else {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp
+}
+
+// gatewayTimeout ответ на промах в автономном режиме (only-if-cached, RFC 9111, 5.2.1.7)
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"X-Cache": []string{"MISS"}},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
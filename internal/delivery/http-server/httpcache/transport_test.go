@@ -0,0 +1,162 @@
+package httpcache
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testBodyLimiter ограничивает все ответы одним размером
+type testBodyLimiter int64
+
+func (l testBodyLimiter) Limit(string) int64 {
+	return int64(l)
+}
+
+func newTestTransport(t *testing.T, limit int64) *Transport {
+	t.Helper()
+
+	store, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTransport(http.DefaultTransport, store, false, testBodyLimiter(limit))
+}
+
+func get(t *testing.T, transport *Transport, url, rangeHeader string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("GET %s (Range %q): %v", url, rangeHeader, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestRangeRequestsBypassCache(t *testing.T) {
+	content := "0123456789abcdefghij"
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		http.ServeContent(w, r, "file.bin", time.Unix(0, 0), strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	transport := newTestTransport(t, 0)
+
+	// Сегменты загрузки получают каждый свою часть
+	for _, segment := range []struct {
+		rangeHeader string
+		want        string
+	}{
+		{rangeHeader: "bytes=0-9", want: "0123456789"},
+		{rangeHeader: "bytes=10-19", want: "abcdefghij"},
+	} {
+		resp, body := get(t, transport, server.URL, segment.rangeHeader)
+		if resp.StatusCode != http.StatusPartialContent || body != segment.want {
+			t.Fatalf("Range %s: status %d, body %q, want 206 %q", segment.rangeHeader, resp.StatusCode, body, segment.want)
+		}
+	}
+
+	// Обычный запрос получает полное тело, а не сохраненную часть
+	resp, body := get(t, transport, server.URL, "")
+	if resp.StatusCode != http.StatusOK || body != content {
+		t.Fatalf("plain GET: status %d, body %q", resp.StatusCode, body)
+	}
+	if atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("server got %d requests, want 3", requests)
+	}
+
+	// Полный ответ уже в кэше, а Range по-прежнему идет на сервер
+	if resp, _ := get(t, transport, server.URL, ""); resp.Header.Get("X-Cache") != "HIT" {
+		t.Fatalf("second plain GET X-Cache = %q, want HIT", resp.Header.Get("X-Cache"))
+	}
+	if resp, body := get(t, transport, server.URL, "bytes=5-7"); resp.Header.Get("X-Cache") != "" || body != "567" {
+		t.Fatalf("Range after HIT: X-Cache %q, body %q", resp.Header.Get("X-Cache"), body)
+	}
+}
+
+func TestPartialResponseIsNotStored(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/file.bin", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusPartialContent,
+		Header:     http.Header{"Cache-Control": []string{"max-age=3600"}},
+	}
+	if storable(req, resp) {
+		t.Fatal("206 response is storable")
+	}
+}
+
+func TestOversizedBodyIsStreamedNotStored(t *testing.T) {
+	content := strings.Repeat("x", 100)
+	for _, tc := range []struct {
+		name    string
+		chunked bool
+	}{
+		{name: "Content-Length"},
+		{name: "unknown length", chunked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=3600")
+				if tc.chunked {
+					w.(http.Flusher).Flush()
+				} else {
+					w.Header().Set("Content-Length", "100")
+				}
+				io.Copy(w, strings.NewReader(content))
+			}))
+			defer server.Close()
+
+			transport := newTestTransport(t, 10)
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			// Клиент получает поток целиком и сам прерывает его по своему лимиту
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != content {
+				t.Fatalf("streamed body has %d bytes, want %d", len(body), len(content))
+			}
+			if _, _, err := transport.store.Get(server.URL); err == nil {
+				t.Fatal("oversized response was stored")
+			}
+		})
+	}
+}
+
+func TestBodyWithinLimitIsStored(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write([]byte("small"))
+	}))
+	defer server.Close()
+
+	transport := newTestTransport(t, 10)
+	get(t, transport, server.URL, "")
+	if _, body, err := transport.store.Get(server.URL); err != nil || !bytes.Equal(body, []byte("small")) {
+		t.Fatalf("stored body %q, err %v", body, err)
+	}
+}
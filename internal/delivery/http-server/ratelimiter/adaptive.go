@@ -0,0 +1,172 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyAlpha вес нового замера в скользящем среднем задержки
+	latencyAlpha = 0.3
+	// latencyFactor во сколько раз задержка должна превысить базовую, чтобы снизить скорость
+	latencyFactor = 2.0
+	// minLatencySamples минимальное число замеров до реакции на рост задержки
+	minLatencySamples = 5
+	// decreaseCooldown минимальный интервал между снижениями скорости хоста.
+	// Если задержка ответа больше, интервал равен ей: ответы на запросы,
+	// отправленные до снижения, не должны снижать скорость повторно
+	decreaseCooldown = time.Second
+)
+
+// hostFeedback накопленная обратная связь от одного хоста
+type hostFeedback struct {
+	successes int           // Успешные ответы с момента последнего изменения скорости
+	samples   int           // Количество замеров задержки
+	latency   time.Duration // Скользящее среднее задержки
+	baseline  time.Duration // Минимальное наблюдавшееся среднее
+	decreased time.Time     // Время последнего снижения скорости
+}
+
+// AdaptiveController регулирует скорость хостов по схеме AIMD:
+// мультипликативно снижает ее при перегрузке сервера и аддитивно
+// повышает, пока ответы остаются здоровыми
+type AdaptiveController struct {
+	registry *HostRegistry
+	minRate  int
+	maxRate  int // 0 означает настроенную скорость хоста
+
+	mu    sync.Mutex
+	hosts map[string]*hostFeedback
+}
+
+// NewAdaptive создает адаптивный контроллер поверх реестра ограничителей
+func NewAdaptive(registry *HostRegistry, minRate, maxRate int) *AdaptiveController {
+	if minRate <= 0 {
+		minRate = 1
+	}
+
+	return &AdaptiveController{
+		registry: registry,
+		minRate:  minRate,
+		maxRate:  maxRate,
+		hosts:    make(map[string]*hostFeedback),
+	}
+}
+
+// Observe учитывает результат запроса к хосту и при необходимости меняет его скорость
+func (a *AdaptiveController) Observe(host string, statusCode int, latency time.Duration, err error) {
+	a.observe(host, statusCode, latency, err, time.Now())
+}
+
+func (a *AdaptiveController) observe(host string, statusCode int, latency time.Duration, err error, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	feedback, exists := a.hosts[host]
+	if !exists {
+		feedback = &hostFeedback{}
+		a.hosts[host] = feedback
+	}
+
+	current := a.registry.Rate(host)
+
+	switch {
+	case isTimeout(err):
+		a.decrease(host, feedback, now, current, current/2, "timeout")
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable:
+		a.decrease(host, feedback, now, current, current/2, http.StatusText(statusCode))
+	case err != nil && statusCode == 0:
+		// Сетевые ошибки без ответа не говорят о нагрузке сервера
+	case a.latencyRising(feedback, latency):
+		a.decrease(host, feedback, now, current, current*3/4, "rising latency")
+	default:
+		feedback.successes++
+		// Повышаем скорость на единицу примерно раз в секунду здоровой работы
+		if feedback.successes >= current {
+			a.increase(host, feedback, current)
+		}
+	}
+}
+
+// latencyRising обновляет скользящее среднее и сообщает, выросла ли задержка
+func (a *AdaptiveController) latencyRising(feedback *hostFeedback, latency time.Duration) bool {
+	if latency <= 0 {
+		return false
+	}
+
+	if feedback.samples == 0 {
+		feedback.latency = latency
+	} else {
+		feedback.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(feedback.latency))
+	}
+	feedback.samples++
+
+	if feedback.baseline == 0 || feedback.latency < feedback.baseline {
+		feedback.baseline = feedback.latency
+	}
+
+	return feedback.samples >= minLatencySamples &&
+		float64(feedback.latency) > latencyFactor*float64(feedback.baseline)
+}
+
+// decrease снижает скорость хоста, не опускаясь ниже минимума и не чаще
+// одного раза за интервал остывания
+func (a *AdaptiveController) decrease(host string, feedback *hostFeedback, now time.Time, current, target int, reason string) {
+	feedback.successes = 0
+
+	cooldown := decreaseCooldown
+	if feedback.latency > cooldown {
+		cooldown = feedback.latency
+	}
+	if now.Sub(feedback.decreased) < cooldown {
+		return
+	}
+
+	if target < a.minRate {
+		target = a.minRate
+	}
+	if target >= current {
+		return
+	}
+
+	// Новая базовая задержка отсчитывается от сниженной скорости
+	feedback.baseline = feedback.latency
+
+	feedback.decreased = now
+	a.registry.SetRate(host, target)
+	log.Printf("Adaptive rate for %s: %d -> %d/sec (%s)", host, current, target, reason)
+}
+
+// increase повышает скорость хоста на единицу, не превышая потолок
+func (a *AdaptiveController) increase(host string, feedback *hostFeedback, current int) {
+	feedback.successes = 0
+
+	ceiling := a.maxRate
+	if ceiling <= 0 {
+		ceiling = a.registry.BaseRate(host)
+	}
+	if current >= ceiling {
+		return
+	}
+
+	a.registry.SetRate(host, current+1)
+	log.Printf("Adaptive rate for %s: %d -> %d/sec (healthy responses)", host, current, current+1)
+}
+
+// isTimeout проверяет, вызвана ли ошибка таймаутом
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,54 @@
+package ratelimiter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"wget-go/internal/config"
+)
+
+func TestDecreaseOncePerCooldown(t *testing.T) {
+	registry := NewRegistry(&config.Config{RateLimit: 16})
+	defer registry.Close()
+	controller := NewAdaptive(registry, 1, 0)
+
+	start := time.Now()
+	// Пачка 429 на запросы, отправленные с прежней скоростью, снижает ее один раз
+	for i := 0; i < 5; i++ {
+		controller.observe("example.com", http.StatusTooManyRequests, 0, nil, start.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	if rate := registry.Rate("example.com"); rate != 8 {
+		t.Fatalf("rate after a burst of 429 = %d, want 8", rate)
+	}
+
+	controller.observe("example.com", http.StatusServiceUnavailable, 0, nil, start.Add(decreaseCooldown))
+	if rate := registry.Rate("example.com"); rate != 4 {
+		t.Fatalf("rate after the cooldown = %d, want 4", rate)
+	}
+
+	// Остывание другого хоста не зависит от первого
+	controller.observe("other.com", http.StatusTooManyRequests, 0, nil, start)
+	if rate := registry.Rate("other.com"); rate != 8 {
+		t.Fatalf("rate of another host = %d, want 8", rate)
+	}
+}
+
+func TestCooldownCoversSlowResponses(t *testing.T) {
+	registry := NewRegistry(&config.Config{RateLimit: 16})
+	defer registry.Close()
+	controller := NewAdaptive(registry, 1, 0)
+
+	start := time.Now()
+	controller.observe("example.com", http.StatusOK, 3*time.Second, nil, start)
+	controller.observe("example.com", http.StatusTooManyRequests, 0, nil, start)
+
+	// Ответы медленнее интервала остывания: ждем одну задержку ответа
+	controller.observe("example.com", http.StatusTooManyRequests, 0, nil, start.Add(2*time.Second))
+	if rate := registry.Rate("example.com"); rate != 8 {
+		t.Fatalf("rate within one response time = %d, want 8", rate)
+	}
+	controller.observe("example.com", http.StatusTooManyRequests, 0, nil, start.Add(3*time.Second))
+	if rate := registry.Rate("example.com"); rate != 4 {
+		t.Fatalf("rate after one response time = %d, want 4", rate)
+	}
+}
@@ -43,12 +43,18 @@ func New(rate int) *TokenBucketRateLimiter {
 
 // fillBucket непрерывно заполняет бакет токенами
 func (rl *TokenBucketRateLimiter) fillBucket() {
+	// Тикер не заменяется после создания, SetRate только перенастраивает его
+	rl.mu.Lock()
+	ticker := rl.ticker
+	rl.mu.Unlock()
+
 	// Бесконечный цикл, пока ограничитель не остановлен или контекст не отменен
 	for {
 		select {
-		case <-rl.ticker.C: // Когда срабатывает тикер
-			rl.mu.Lock()     // Блокируем мьютекс для безопасного доступа к полям
-			if !rl.stopped { // Если ограничитель не остановлен
+		case <-ticker.C: // Когда срабатывает тикер
+			rl.mu.Lock() // Блокируем мьютекс для безопасного доступа к полям
+			// Если ограничитель не остановлен и бакет не больше текущей скорости
+			if !rl.stopped && len(rl.tokens) < rl.rate {
 				select {
 				case rl.tokens <- struct{}{}: // Пытаемся добавить токен в канал (бакет)
 				default:
@@ -64,9 +70,13 @@ func (rl *TokenBucketRateLimiter) fillBucket() {
 
 // Wait ожидает доступный токен
 func (rl *TokenBucketRateLimiter) Wait(ctx context.Context) error {
+	rl.mu.Lock()
+	tokens := rl.tokens
+	rl.mu.Unlock()
+
 	// Используем select для ожидания либо доступного токена, либо отмены контекста
 	select {
-	case <-rl.tokens: // Если удалось получить токен из канала
+	case <-tokens: // Если удалось получить токен из канала
 		return nil // Операция разрешена
 	case <-ctx.Done(): // Если внешний контекст пользователя отменен (таймаут, явная отмена)
 		return ctx.Err() // Возвращаем ошибку внешнего контекста
@@ -75,7 +85,10 @@ func (rl *TokenBucketRateLimiter) Wait(ctx context.Context) error {
 	}
 }
 
-// SetRate изменяет скорость ограничителя
+// SetRate изменяет скорость ограничителя. Канал токенов и тикер не
+// заменяются, а перенастраиваются: ожидающие в Wait продолжают ждать тот
+// же канал и получают токены уже с новой частотой. Емкость бакета задана
+// при создании, поэтому при повышении скорости всплеск запросов не растет
 func (rl *TokenBucketRateLimiter) SetRate(rate int) {
 	// Если заданная скорость некорректна, устанавливаем минимальную
 	if rate <= 0 {
@@ -90,40 +103,25 @@ func (rl *TokenBucketRateLimiter) SetRate(rate int) {
 		return
 	}
 
-	// Останавливаем старый тикер
-	rl.ticker.Stop()
-	// Создаем новый тикер с новой скоростью
-	rl.ticker = time.NewTicker(time.Second / time.Duration(rate))
-	// Обновляем значение скорости
+	// Перенастраиваем тикер на новую частоту
+	rl.ticker.Reset(time.Second / time.Duration(rate))
 	rl.rate = rate
 
-	// Создаем новый канал токенов с новой емкостью
-	newTokens := make(chan struct{}, rate)
-
-	// Переносим существующие токены (не более новой емкости)
-	transfer := true            // Флаг для контроля переноса
-	for i := 0; i < rate; i++ { // Итерируемся до новой скорости (максимальная емкость нового бакета)
+	// При снижении скорости убираем лишние накопленные токены, чтобы
+	// всплеск не превышал новую скорость
+	for len(rl.tokens) > rate {
 		select {
-		case token := <-rl.tokens: // Пытаемся неблокирующе извлечь токен из старого бакета
-			select {
-			case newTokens <- token: // Пытаемся неблокирующе записать токен в новый бакет
-			default:
-				// Новый бакет заполнен, прекращаем перенос
-				transfer = false
-			}
+		case <-rl.tokens:
 		default:
-			// Больше нет токенов для переноса из старого бакета
-			transfer = false
-		}
-
-		// Если перенос прекращен, выходим из цикла
-		if !transfer {
-			break
 		}
 	}
+}
 
-	// Атомарно заменяем старый канал токенов новым
-	rl.tokens = newTokens
+// Rate возвращает текущую скорость ограничителя
+func (rl *TokenBucketRateLimiter) Rate() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rate
 }
 
 // Stop останавливает ограничитель
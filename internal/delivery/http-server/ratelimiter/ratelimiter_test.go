@@ -0,0 +1,78 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"wget-go/internal/config"
+)
+
+func TestSetRateWakesPendingWait(t *testing.T) {
+	limiter := New(1)
+	defer limiter.Stop()
+
+	// Забираем накопленные токены, чтобы следующий Wait заблокировался
+	for len(limiter.tokens) > 0 {
+		<-limiter.tokens
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- limiter.Wait(ctx)
+		}()
+	}
+
+	// Меняем скорость, пока ожидающие уже стоят в Wait
+	time.Sleep(50 * time.Millisecond)
+	limiter.SetRate(2)
+	limiter.SetRate(50)
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Wait after SetRate: %v", err)
+		}
+	}
+	if rate := limiter.Rate(); rate != 50 {
+		t.Fatalf("Rate() = %d, want 50", rate)
+	}
+}
+
+func TestSetRateLowersBurst(t *testing.T) {
+	limiter := New(20)
+	defer limiter.Stop()
+
+	time.Sleep(1100 * time.Millisecond)
+	limiter.SetRate(2)
+
+	if burst := len(limiter.tokens); burst > 2 {
+		t.Fatalf("burst after SetRate(2) = %d, want at most 2", burst)
+	}
+}
+
+func TestRegistryKeepsAdjustedRateAfterIdle(t *testing.T) {
+	registry := NewRegistry(&config.Config{RateLimit: 10})
+	defer registry.Close()
+
+	registry.SetRate("example.com", 3)
+	registry.removeIdle(time.Now().Add(time.Second))
+
+	if rate := registry.Rate("example.com"); rate != 3 {
+		t.Fatalf("rate after idle collection = %d, want 3", rate)
+	}
+	if err := registry.Wait(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if rate := registry.Rate("example.com"); rate != 3 {
+		t.Fatalf("rate of recreated limiter = %d, want 3", rate)
+	}
+}
@@ -0,0 +1,289 @@
+package ratelimiter
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+	"wget-go/internal/config"
+)
+
+const (
+	// idleTTL время простоя, после которого ограничитель хоста удаляется
+	idleTTL = 5 * time.Minute
+	// gcInterval период проверки простаивающих ограничителей
+	gcInterval = time.Minute
+)
+
+// hostEntry состояние вежливости для одного хоста
+type hostEntry struct {
+	limiter *TokenBucketRateLimiter // Собственный бакет хоста
+	wait    time.Duration           // Пауза между запросами к хосту
+
+	mu          sync.Mutex // Защищает nextAllowed
+	nextAllowed time.Time  // Момент, раньше которого нельзя слать следующий запрос
+
+	active   int       // Количество запросов, ожидающих в Wait
+	lastUsed time.Time // Время последнего обращения
+}
+
+// HostRegistry хранит независимые ограничители для каждого хоста
+type HostRegistry struct {
+	mu          sync.Mutex
+	hosts       map[string]*hostEntry
+	defaultRate int
+	wait        time.Duration
+	randomWait  bool
+	overrides   map[string]config.HostConfig
+	minWaits    map[string]time.Duration // Паузы из Crawl-delay, переживают удаление ограничителя
+	rates       map[string]int           // Скорости из SetRate, переживают удаление ограничителя
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewRegistry создает реестр ограничителей по хостам
+func NewRegistry(cfg *config.Config) *HostRegistry {
+	registry := &HostRegistry{
+		hosts:       make(map[string]*hostEntry),
+		defaultRate: cfg.RateLimit,
+		wait:        cfg.Wait,
+		randomWait:  cfg.RandomWait,
+		overrides:   cfg.Hosts,
+		minWaits:    make(map[string]time.Duration),
+		rates:       make(map[string]int),
+		stop:        make(chan struct{}),
+	}
+
+	go registry.collectIdle()
+	return registry
+}
+
+// Wait ожидает разрешения на запрос к хосту: токен из бакета хоста и паузу -wait
+func (r *HostRegistry) Wait(ctx context.Context, host string) error {
+	entry := r.acquire(host)
+	defer r.release(entry)
+
+	if err := entry.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	delay := r.reserve(entry)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve резервирует слот для запроса и возвращает, сколько нужно подождать
+func (r *HostRegistry) reserve(entry *hostEntry) time.Duration {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	now := time.Now()
+	start := entry.nextAllowed
+	if start.Before(now) {
+		start = now
+	}
+	entry.nextAllowed = start.Add(r.jitter(entry.wait))
+
+	return start.Sub(now)
+}
+
+// jitter применяет -random-wait к паузе
+func (r *HostRegistry) jitter(wait time.Duration) time.Duration {
+	if !r.randomWait || wait <= 0 {
+		return wait
+	}
+	return time.Duration((0.5 + rand.Float64()) * float64(wait))
+}
+
+// acquire возвращает состояние хоста, создавая его при необходимости
+func (r *HostRegistry) acquire(host string) *hostEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.hosts[host]
+	if !exists {
+		entry = &hostEntry{
+			limiter: New(r.rateFor(host)),
+			wait:    r.waitFor(host),
+		}
+		r.hosts[host] = entry
+	}
+
+	entry.active++
+	entry.lastUsed = time.Now()
+	return entry
+}
+
+// release отмечает завершение ожидания
+func (r *HostRegistry) release(entry *hostEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.active--
+	entry.lastUsed = time.Now()
+}
+
+// SetRate изменяет скорость ограничителя хоста. Скорость запоминается,
+// чтобы сниженная адаптацией скорость не сбрасывалась к настроенной после
+// удаления простаивающего ограничителя
+func (r *HostRegistry) SetRate(host string, rate int) {
+	entry := r.acquire(host)
+	defer r.release(entry)
+
+	r.mu.Lock()
+	r.rates[host] = rate
+	r.mu.Unlock()
+
+	entry.limiter.SetRate(rate)
+}
+
+// Rate возвращает текущую скорость ограничителя хоста
+func (r *HostRegistry) Rate(host string) int {
+	r.mu.Lock()
+	entry, exists := r.hosts[host]
+	r.mu.Unlock()
+
+	if !exists {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.rateFor(host)
+	}
+	return entry.limiter.Rate()
+}
+
+// SlowDown задает минимальную паузу между запросами к хосту, например из
+// Crawl-delay. Настроенная пауза больше минимальной сохраняется.
+// Возвращает true, если пауза хоста увеличилась
+func (r *HostRegistry) SlowDown(host string, wait time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, configured := r.settingsFor(host)
+	r.minWaits[host] = wait
+	if wait <= configured {
+		return false
+	}
+
+	if entry, exists := r.hosts[host]; exists {
+		entry.mu.Lock()
+		entry.wait = wait
+		entry.mu.Unlock()
+	}
+	return true
+}
+
+// BaseRate возвращает настроенную скорость хоста без учета адаптации
+func (r *HostRegistry) BaseRate(host string) int {
+	rate, _ := r.settingsFor(host)
+	return rate
+}
+
+// Rates возвращает текущие скорости всех активных хостов
+func (r *HostRegistry) Rates() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rates := make(map[string]int, len(r.hosts))
+	for host, entry := range r.hosts {
+		rates[host] = entry.limiter.Rate()
+	}
+	return rates
+}
+
+// rateFor возвращает скорость хоста с учетом скорости из SetRate.
+// Вызывается под r.mu
+func (r *HostRegistry) rateFor(host string) int {
+	if rate, adjusted := r.rates[host]; adjusted {
+		return rate
+	}
+	rate, _ := r.settingsFor(host)
+	return rate
+}
+
+// waitFor возвращает паузу хоста с учетом минимальной паузы из SlowDown.
+// Вызывается под r.mu
+func (r *HostRegistry) waitFor(host string) time.Duration {
+	_, wait := r.settingsFor(host)
+	if minWait := r.minWaits[host]; minWait > wait {
+		return minWait
+	}
+	return wait
+}
+
+// settingsFor возвращает скорость и паузу для хоста с учетом переопределений
+func (r *HostRegistry) settingsFor(host string) (int, time.Duration) {
+	rate, wait := r.defaultRate, r.wait
+
+	override, exists := r.overrides[host]
+	if !exists {
+		// Переопределение может быть задано без порта
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			override, exists = r.overrides[hostname]
+		}
+	}
+
+	if exists {
+		if override.RateLimit > 0 {
+			rate = override.RateLimit
+		}
+		if override.Wait.Duration > 0 {
+			wait = override.Wait.Duration
+		}
+	}
+	return rate, wait
+}
+
+// collectIdle периодически удаляет ограничители простаивающих хостов
+func (r *HostRegistry) collectIdle() {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.removeIdle(time.Now().Add(-idleTTL))
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// removeIdle останавливает и удаляет ограничители, не использовавшиеся с момента deadline
+func (r *HostRegistry) removeIdle(deadline time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for host, entry := range r.hosts {
+		if entry.active == 0 && entry.lastUsed.Before(deadline) {
+			entry.limiter.Stop()
+			delete(r.hosts, host)
+		}
+	}
+}
+
+// Close останавливает сборщик и все ограничители
+func (r *HostRegistry) Close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for host, entry := range r.hosts {
+			entry.limiter.Stop()
+			delete(r.hosts, host)
+		}
+	})
+}
@@ -1,38 +1,70 @@
 package robots
 
 import (
-	"bufio"
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
 	httpserver "wget-go/internal/delivery/http-server"
 )
 
-// RobotsTxt представляет правила из robots.txt
-type RobotsTxt struct {
-	rules map[string][]string // user-agent -> disallow paths
+const (
+	// cacheTTL сколько действует загруженный robots.txt (RFC 9309, раздел 2.4)
+	cacheTTL = 24 * time.Hour
+	// retryTTL через сколько повторить загрузку после 5xx или недоступности сервера
+	retryTTL = time.Minute
+	// fetchTimeout таймаут загрузки robots.txt
+	fetchTimeout = 10 * time.Second
+)
+
+// cacheEntry robots.txt одного источника (схема, хост и порт)
+type cacheEntry struct {
+	robots    *RobotsTxt
+	expires   time.Time
+	available bool   // false - правила получены не из файла (5xx или сеть)
+	status    string // Как получены правила, для диагностики
+}
+
+// fetchCall загрузка robots.txt, которую ожидают конкурентные запросы
+type fetchCall struct {
+	done  chan struct{}
+	entry *cacheEntry
 }
 
 // RobotsCheckerImpl реализация RobotsChecker
 type RobotsCheckerImpl struct {
-	client    httpserver.Client
-	userAgent string
-	cache     map[string]*RobotsTxt
-	mu        sync.RWMutex
+	client        httpserver.Client
+	pacer         httpserver.HostPacer // nil - Crawl-delay не учитывается
+	maxCrawlDelay time.Duration        // Верхняя граница паузы из Crawl-delay
+	userAgent     string
+
+	mu       sync.Mutex
+	cache    map[string]*cacheEntry
+	inflight map[string]*fetchCall
 }
 
-// New создает новый проверщик robots.txt
-func New(client httpserver.Client) *RobotsCheckerImpl {
+// New создает новый проверщик robots.txt. Crawl-delay и Request-rate
+// передаются в pacer, но не больше maxCrawlDelay (0 - не учитываются)
+func New(client httpserver.Client, pacer httpserver.HostPacer, maxCrawlDelay time.Duration) *RobotsCheckerImpl {
 	return &RobotsCheckerImpl{
-		client: client,
-		cache:  make(map[string]*RobotsTxt),
+		client:        client,
+		pacer:         pacer,
+		maxCrawlDelay: maxCrawlDelay,
+		cache:         make(map[string]*cacheEntry),
+		inflight:      make(map[string]*fetchCall),
 	}
 }
 
+// SetClient задает клиент для загрузки robots.txt. Позволяет загружать файл
+// тем же клиентом, который проверяет правила перед запросами
+func (r *RobotsCheckerImpl) SetClient(client httpserver.Client) {
+	r.client = client
+}
+
 // SetUserAgent устанавливает User-Agent для проверки
 func (r *RobotsCheckerImpl) SetUserAgent(ua string) {
 	r.userAgent = ua
@@ -44,117 +76,149 @@ func (r *RobotsCheckerImpl) IsAllowed(rawUrl string) bool {
 		return true // При ошибке парсинга разрешаем доступ
 	}
 
-	domain := parsedUrl.Host
-	path := parsedUrl.Path
+	// robots.txt разрешен всегда, в том числе запрос самого проверщика
+	if parsedUrl.EscapedPath() == "/robots.txt" {
+		return true
+	}
+
+	return r.getRobotsTxt(parsedUrl.Scheme, parsedUrl.Host).robots.DecideURL(r.userAgent, parsedUrl).Allowed
+}
 
-	// Получаем robots.txt для домена
-	robotsTxt, err := r.getRobotsTxt(domain)
+// Rules возвращает правила robots.txt для URL и описание того, как они
+// получены: загружены, отсутствуют (4xx) или сервер недоступен
+func (r *RobotsCheckerImpl) Rules(rawUrl string) (*RobotsTxt, string, error) {
+	parsedUrl, err := url.Parse(rawUrl)
 	if err != nil {
-		return true // При ошибке загрузки разрешаем доступ
+		return nil, "", err
+	}
+	if parsedUrl.Scheme == "" || parsedUrl.Host == "" {
+		return nil, "", fmt.Errorf("URL must be absolute: %s", rawUrl)
 	}
+	entry := r.getRobotsTxt(parsedUrl.Scheme, parsedUrl.Host)
+	return entry.robots, entry.status, nil
+}
 
-	return robotsTxt.IsAllowed(r.userAgent, path)
+// Sitemaps возвращает адреса sitemap из robots.txt сайта, которому принадлежит URL
+func (r *RobotsCheckerImpl) Sitemaps(rawUrl string) []string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil
+	}
+	return r.getRobotsTxt(parsedUrl.Scheme, parsedUrl.Host).robots.Sitemaps()
 }
 
-func (r *RobotsCheckerImpl) getRobotsTxt(domain string) (*RobotsTxt, error) {
-	r.mu.RLock()
-	robotsTxt, exists := r.cache[domain]
-	r.mu.RUnlock()
+// getRobotsTxt возвращает правила источника из кэша или загружает их.
+// Одновременные запросы к одному источнику ожидают одну загрузку
+func (r *RobotsCheckerImpl) getRobotsTxt(scheme, host string) *cacheEntry {
+	origin := scheme + "://" + host
 
-	if exists {
-		return robotsTxt, nil
+	r.mu.Lock()
+	entry, cached := r.cache[origin]
+	if cached && time.Now().Before(entry.expires) {
+		r.mu.Unlock()
+		return entry
 	}
 
-	// Загружаем robots.txt
-	robotsURL := fmt.Sprintf("https://%s/robots.txt", domain)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	call, loading := r.inflight[origin]
+	if !loading {
+		call = &fetchCall{done: make(chan struct{})}
+		r.inflight[origin] = call
+	}
+	r.mu.Unlock()
 
-	content, _, err := r.client.Get(ctx, robotsURL)
-	if err != nil {
-		// Если не удалось загрузить, создаем пустой robots.txt
-		robotsTxt = &RobotsTxt{rules: make(map[string][]string)}
-	} else {
-		// Парсим robots.txt
-		robotsTxt = parseRobotsTxt(content)
+	if loading {
+		<-call.done
+		return call.entry
 	}
 
+	call.entry = r.fetch(origin, host, entry)
+
 	r.mu.Lock()
-	r.cache[domain] = robotsTxt
+	r.cache[origin] = call.entry
+	delete(r.inflight, origin)
 	r.mu.Unlock()
+	close(call.done)
 
-	return robotsTxt, nil
+	return call.entry
 }
 
-// parseRobotsTxt парсит содержимое robots.txt
-func parseRobotsTxt(content []byte) *RobotsTxt {
-	scanner := bufio.NewScanner(bytes.NewReader(content))
-	rules := make(map[string][]string)
-	var currentAgent string
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Пропускаем комментарии и пустые строки
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
+// fetch загружает robots.txt источника по правилам RFC 9309: ответ 4xx
+// разрешает все, 5xx и недоступность сервера временно запрещают все.
+// previous - устаревшая запись кэша, ее правила используются вместо запрета
+func (r *RobotsCheckerImpl) fetch(origin, host string, previous *cacheEntry) *cacheEntry {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
 
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) < 2 {
-			continue
+	robotsURL := origin + "/robots.txt"
+	resp, err := r.client.Get(ctx, robotsURL)
+	if err == nil {
+		robotsTxt := Parse(resp.Body)
+		r.applyCrawlDelay(host, robotsTxt)
+		return &cacheEntry{
+			robots:    robotsTxt,
+			expires:   time.Now().Add(cacheTTL),
+			available: true,
+			status:    fmt.Sprintf("fetched %s (%d bytes)", resp.URL, len(resp.Body)),
 		}
+	}
 
-		key := strings.TrimSpace(strings.ToLower(parts[0]))
-		value := strings.TrimSpace(parts[1])
-
-		switch key {
-		case "user-agent":
-			currentAgent = value
-			if _, exists := rules[currentAgent]; !exists {
-				rules[currentAgent] = []string{}
-			}
-		case "disallow":
-			if currentAgent != "" && value != "" {
-				rules[currentAgent] = append(rules[currentAgent], value)
-			}
-		case "allow":
-			// Можно добавить поддержку allow директив при необходимости
+	var statusErr *httpserver.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusBadRequest && statusErr.StatusCode < http.StatusInternalServerError {
+		// Файла нет или доступ к нему закрыт: ограничений нет
+		return &cacheEntry{
+			robots:    &RobotsTxt{},
+			expires:   time.Now().Add(cacheTTL),
+			available: true,
+			status:    fmt.Sprintf("%s returned HTTP %d, everything is allowed", robotsURL, statusErr.StatusCode),
 		}
 	}
 
-	return &RobotsTxt{rules: rules}
-}
-
-// IsAllowed проверяет, разрешен ли путь для указанного User-Agent
-func (r *RobotsTxt) IsAllowed(userAgent string, path string) bool {
-	// Проверяем правила для конкретного User-Agent
-	if disallows, exists := r.rules[userAgent]; exists {
-		if r.isPathDisallowed(path, disallows) {
-			return false
+	// Сервер временно недоступен: продолжаем пользоваться прежними
+	// правилами, если они были, иначе запрещаем все до повторной попытки
+	if previous != nil && previous.available {
+		log.Printf("robots.txt for %s unavailable, keeping cached rules: %v", origin, err)
+		return &cacheEntry{
+			robots:    previous.robots,
+			expires:   time.Now().Add(retryTTL),
+			available: true,
+			status:    previous.status + ", kept after error: " + err.Error(),
 		}
 	}
 
-	// Проверяем правила для агентов (*)
-	if disallows, exists := r.rules["*"]; exists {
-		if r.isPathDisallowed(path, disallows) {
-			return false
-		}
+	log.Printf("robots.txt for %s unavailable, disallowing all for %s: %v", origin, retryTTL, err)
+	return &cacheEntry{
+		robots:  disallowAll(),
+		expires: time.Now().Add(retryTTL),
+		status:  fmt.Sprintf("%s unavailable, everything is disallowed: %v", robotsURL, err),
 	}
+}
 
-	return true
+// disallowAll возвращает правила, запрещающие любой путь
+func disallowAll() *RobotsTxt {
+	return &RobotsTxt{groups: []*Group{{
+		Agents: []string{"*"},
+		Rules:  []*Rule{{Pattern: "/", normalized: "/"}},
+	}}}
 }
 
-// isPathDisallowed проверяет, является ли путь запрещенным
-func (r *RobotsTxt) isPathDisallowed(path string, disallows []string) bool {
-	for _, disallow := range disallows {
-		if disallow == "" {
-			continue
-		}
+// applyCrawlDelay передает паузу из группы User-Agent в ограничитель хоста
+func (r *RobotsCheckerImpl) applyCrawlDelay(host string, robotsTxt *RobotsTxt) {
+	if r.pacer == nil || r.maxCrawlDelay <= 0 {
+		return
+	}
+	group := robotsTxt.Group(r.userAgent)
+	if group == nil || group.CrawlDelay <= 0 {
+		return
+	}
 
-		if strings.HasPrefix(path, disallow) {
-			return true
-		}
+	delay := group.CrawlDelay
+	note := ""
+	if delay > r.maxCrawlDelay {
+		note = fmt.Sprintf(" (capped from %s)", delay)
+		delay = r.maxCrawlDelay
+	}
+
+	if r.pacer.SlowDown(host, delay) {
+		log.Printf("robots.txt slows down %s: %s between requests%s", host, delay, note)
 	}
-	return false
 }
@@ -0,0 +1,250 @@
+package robots
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+// testClient загружает файлы с тестового сервера и, как настоящий клиент,
+// возвращает StatusError для ответов 4xx и 5xx
+type testClient struct{}
+
+func (testClient) Get(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &httpserver.StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &httpserver.Response{URL: rawURL, StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+func (c testClient) Head(ctx context.Context, rawURL string) (*httpserver.Response, error) {
+	return c.Get(ctx, rawURL)
+}
+
+// robotsServer отдает robots.txt с изменяемым статусом и считает загрузки
+type robotsServer struct {
+	*httptest.Server
+	status  atomic.Int32
+	body    atomic.Value
+	fetches atomic.Int32
+	release chan struct{} // Если не nil, ответ ждет закрытия канала
+}
+
+func newRobotsServer(t *testing.T, status int, body string) *robotsServer {
+	t.Helper()
+
+	s := &robotsServer{}
+	s.status.Store(int32(status))
+	s.body.Store(body)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		s.fetches.Add(1)
+		if s.release != nil {
+			<-s.release
+		}
+		w.WriteHeader(int(s.status.Load()))
+		io.WriteString(w, s.body.Load().(string))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// expire помечает закэшированные правила сервера устаревшими
+func expire(t *testing.T, checker *RobotsCheckerImpl, serverURL string) {
+	t.Helper()
+
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+	entry, ok := checker.cache[serverURL]
+	if !ok {
+		t.Fatalf("no cached robots.txt for %s", serverURL)
+	}
+	entry.expires = time.Now().Add(-time.Second)
+}
+
+func newChecker(pacer httpserver.HostPacer, maxCrawlDelay time.Duration) *RobotsCheckerImpl {
+	checker := New(testClient{}, pacer, maxCrawlDelay)
+	checker.SetUserAgent("Wget-Go/1.0")
+	return checker
+}
+
+func TestMissingRobotsAllowsEverything(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden, http.StatusUnauthorized} {
+		server := newRobotsServer(t, status, "User-agent: *\nDisallow: /\n")
+		checker := newChecker(nil, 0)
+
+		if !checker.IsAllowed(server.URL + "/private/page.html") {
+			t.Errorf("HTTP %d: page disallowed", status)
+		}
+		if _, how, _ := checker.Rules(server.URL + "/"); !strings.Contains(how, "everything is allowed") {
+			t.Errorf("HTTP %d: rules status %q", status, how)
+		}
+	}
+}
+
+func TestUnavailableRobotsDisallowsTemporarily(t *testing.T) {
+	server := newRobotsServer(t, http.StatusServiceUnavailable, "")
+	checker := newChecker(nil, 0)
+
+	if checker.IsAllowed(server.URL + "/page.html") {
+		t.Fatal("page allowed while robots.txt returns 503")
+	}
+	checker.mu.Lock()
+	expires := checker.cache[server.URL].expires
+	checker.mu.Unlock()
+	if time.Until(expires) > retryTTL {
+		t.Fatalf("disallow-all kept until %v, longer than the retry interval", expires)
+	}
+
+	// После повторной попытки действуют правила из файла
+	server.status.Store(http.StatusOK)
+	server.body.Store("User-agent: *\nDisallow: /private\n")
+	expire(t, checker, server.URL)
+	if !checker.IsAllowed(server.URL+"/page.html") || checker.IsAllowed(server.URL+"/private/a") {
+		t.Fatal("rules from the recovered robots.txt not applied")
+	}
+}
+
+func TestStaleRulesKeptOnError(t *testing.T) {
+	server := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	checker := newChecker(nil, 0)
+	checker.IsAllowed(server.URL + "/")
+
+	server.status.Store(http.StatusInternalServerError)
+	expire(t, checker, server.URL)
+
+	if !checker.IsAllowed(server.URL + "/page.html") {
+		t.Error("server error replaced cached rules with disallow-all")
+	}
+	if checker.IsAllowed(server.URL + "/private/a") {
+		t.Error("cached Disallow lost after server error")
+	}
+	if server.fetches.Load() != 2 {
+		t.Fatalf("robots.txt fetched %d times, want 2", server.fetches.Load())
+	}
+}
+
+func TestRulesCachedUntilExpired(t *testing.T) {
+	server := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	checker := newChecker(nil, 0)
+
+	for _, path := range []string{"/", "/a", "/private/b", "/c?d=e"} {
+		checker.IsAllowed(server.URL + path)
+	}
+	if server.fetches.Load() != 1 {
+		t.Fatalf("robots.txt fetched %d times within TTL, want 1", server.fetches.Load())
+	}
+
+	checker.mu.Lock()
+	expires := checker.cache[server.URL].expires
+	checker.mu.Unlock()
+	if left := time.Until(expires); left < cacheTTL-time.Minute || left > cacheTTL {
+		t.Fatalf("rules expire in %v, want about %v", left, cacheTTL)
+	}
+
+	expire(t, checker, server.URL)
+	checker.IsAllowed(server.URL + "/a")
+	if server.fetches.Load() != 2 {
+		t.Fatalf("expired robots.txt fetched %d times in total, want 2", server.fetches.Load())
+	}
+}
+
+func TestConcurrentChecksShareOneFetch(t *testing.T) {
+	server := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /private\n")
+	server.release = make(chan struct{})
+	checker := newChecker(nil, 0)
+
+	var wg sync.WaitGroup
+	results := make([]bool, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = checker.IsAllowed(server.URL + "/private/page")
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(server.release)
+	wg.Wait()
+
+	if server.fetches.Load() != 1 {
+		t.Fatalf("robots.txt fetched %d times, want 1", server.fetches.Load())
+	}
+	for i, allowed := range results {
+		if allowed {
+			t.Fatalf("check %d allowed a disallowed page", i)
+		}
+	}
+}
+
+// recordingPacer запоминает паузы, заданные хостам
+type recordingPacer struct {
+	mu     sync.Mutex
+	delays map[string]time.Duration
+}
+
+func (p *recordingPacer) SlowDown(host string, wait time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.delays[host] = wait
+	return true
+}
+
+func TestCrawlDelayIsCapped(t *testing.T) {
+	server := newRobotsServer(t, http.StatusOK, "User-agent: *\nCrawl-delay: 30\n\nUser-agent: Wget-Go\nRequest-rate: 1/2\n")
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	for _, tc := range []struct {
+		name      string
+		userAgent string
+		max       time.Duration
+		want      time.Duration
+		applied   bool
+	}{
+		{name: "own group", userAgent: "Wget-Go/1.0", max: 10 * time.Second, want: 2 * time.Second, applied: true},
+		{name: "capped", userAgent: "OtherBot", max: 10 * time.Second, want: 10 * time.Second, applied: true},
+		{name: "disabled", userAgent: "OtherBot"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pacer := &recordingPacer{delays: make(map[string]time.Duration)}
+			checker := New(testClient{}, pacer, tc.max)
+			checker.SetUserAgent(tc.userAgent)
+			checker.IsAllowed(server.URL + "/")
+
+			got, applied := pacer.delays[host]
+			if applied != tc.applied || got != tc.want {
+				t.Fatalf("pacer got %v (applied %v), want %v (applied %v)", got, applied, tc.want, tc.applied)
+			}
+		})
+	}
+}
+
+func TestRulesRequiresAbsoluteURL(t *testing.T) {
+	if _, _, err := newChecker(nil, 0).Rules("/relative/path"); err == nil {
+		t.Fatal("Rules accepted a relative URL")
+	}
+}
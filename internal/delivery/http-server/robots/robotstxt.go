@@ -0,0 +1,388 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRobotsSize сколько байт robots.txt разбирается (RFC 9309, раздел 2.5)
+const maxRobotsSize = 500 * 1024
+
+// Rule правило Allow или Disallow
+type Rule struct {
+	Allow   bool   // true для Allow, false для Disallow
+	Pattern string // Путь из файла как есть
+	Line    int    // Номер строки в файле
+
+	normalized string // Путь после нормализации процентного кодирования
+}
+
+// String возвращает правило в виде строки robots.txt
+func (r *Rule) String() string {
+	if r.Allow {
+		return "Allow: " + r.Pattern
+	}
+	return "Disallow: " + r.Pattern
+}
+
+// Group группа правил для одного или нескольких user-agent
+type Group struct {
+	Agents     []string // Значения строк User-agent
+	Rules      []*Rule
+	CrawlDelay time.Duration // Пауза из Crawl-delay или Request-rate (0 - не задана)
+	Line       int           // Номер первой строки User-agent группы
+}
+
+// RobotsTxt разобранный robots.txt
+type RobotsTxt struct {
+	groups   []*Group
+	sitemaps []string // Значения строк Sitemap в порядке появления
+}
+
+// Decision результат проверки пути
+type Decision struct {
+	Allowed bool
+	Group   *Group // Примененная группа (nil - подходящей группы нет)
+	Rule    *Rule  // Решающее правило (nil - ни одно правило не подошло)
+}
+
+// Parse разбирает robots.txt согласно RFC 9309
+func Parse(content []byte) *RobotsTxt {
+	if len(content) > maxRobotsSize {
+		content = content[:maxRobotsSize]
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	robots := &RobotsTxt{}
+	var current *Group
+	inRules := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRobotsSize)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+
+		// Комментарий может начинаться в любом месте строки
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Строка user-agent после правил начинает новую группу,
+			// подряд идущие строки относятся к одной группе
+			if current == nil || inRules {
+				current = &Group{Line: lineNumber}
+				robots.groups = append(robots.groups, current)
+				inRules = false
+			}
+			current.Agents = append(current.Agents, value)
+		case "allow", "disallow":
+			// Правила вне группы игнорируются
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// Пустой путь не является правилом
+			if value == "" {
+				continue
+			}
+			current.Rules = append(current.Rules, &Rule{
+				Allow:      key == "allow",
+				Pattern:    value,
+				Line:       lineNumber,
+				normalized: normalizePath(value),
+			})
+		case "crawl-delay", "request-rate":
+			if current == nil {
+				continue
+			}
+			inRules = true
+
+			var delay time.Duration
+			var ok bool
+			if key == "crawl-delay" {
+				delay, ok = parseCrawlDelay(value)
+			} else {
+				delay, ok = parseRequestRate(value)
+			}
+			// Из нескольких значений берем самое вежливое
+			if ok && delay > current.CrawlDelay {
+				current.CrawlDelay = delay
+			}
+		case "sitemap":
+			// Sitemap не относится к группам и может стоять в любом месте файла
+			if value != "" {
+				robots.sitemaps = append(robots.sitemaps, value)
+			}
+		}
+	}
+
+	return robots
+}
+
+// parseCrawlDelay разбирает Crawl-delay: число секунд, возможно дробное
+func parseCrawlDelay(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// parseRequestRate разбирает Request-rate вида "1/5", "1/5s", "10/1m" или "1/1h"
+// и возвращает паузу между запросами. Временное окно после значения не учитывается
+func parseRequestRate(value string) (time.Duration, bool) {
+	if fields := strings.Fields(value); len(fields) > 0 {
+		value = fields[0]
+	}
+	requestsPart, periodPart, found := strings.Cut(value, "/")
+	if !found {
+		return 0, false
+	}
+
+	requests, err := strconv.Atoi(requestsPart)
+	if err != nil || requests <= 0 {
+		return 0, false
+	}
+
+	unit := time.Second
+	switch {
+	case strings.HasSuffix(periodPart, "s"):
+		periodPart = strings.TrimSuffix(periodPart, "s")
+	case strings.HasSuffix(periodPart, "m"):
+		unit = time.Minute
+		periodPart = strings.TrimSuffix(periodPart, "m")
+	case strings.HasSuffix(periodPart, "h"):
+		unit = time.Hour
+		periodPart = strings.TrimSuffix(periodPart, "h")
+	}
+
+	period, err := strconv.ParseFloat(periodPart, 64)
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+	return time.Duration(period * float64(unit) / float64(requests)), true
+}
+
+// Sitemaps возвращает адреса sitemap, объявленные в файле
+func (r *RobotsTxt) Sitemaps() []string {
+	return r.sitemaps
+}
+
+// DecideURL проверяет URL: правила сравниваются с путем и запросом
+// в исходном (экранированном) виде
+func (r *RobotsTxt) DecideURL(userAgent string, u *url.URL) Decision {
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return r.Decide(userAgent, path)
+}
+
+// IsAllowed проверяет, разрешен ли путь для указанного User-Agent
+func (r *RobotsTxt) IsAllowed(userAgent string, path string) bool {
+	return r.Decide(userAgent, path).Allowed
+}
+
+// Decide находит группу для User-Agent и решающее правило для пути.
+// path - путь URL с запросом в исходном (экранированном) виде
+func (r *RobotsTxt) Decide(userAgent string, path string) Decision {
+	group := r.Group(userAgent)
+	if group == nil {
+		return Decision{Allowed: true}
+	}
+
+	// /robots.txt разрешен всегда
+	if path == "/robots.txt" {
+		return Decision{Allowed: true, Group: group}
+	}
+
+	if path == "" {
+		path = "/"
+	}
+	normalized := normalizePath(path)
+
+	// Побеждает самое длинное совпадение, при равной длине - Allow
+	var best *Rule
+	for _, rule := range group.Rules {
+		if !matchPattern(rule.normalized, normalized) {
+			continue
+		}
+		if best == nil ||
+			len(rule.normalized) > len(best.normalized) ||
+			(len(rule.normalized) == len(best.normalized) && rule.Allow && !best.Allow) {
+			best = rule
+		}
+	}
+
+	if best == nil {
+		return Decision{Allowed: true, Group: group}
+	}
+	return Decision{Allowed: best.Allow, Group: group, Rule: best}
+}
+
+// Group возвращает группу для User-Agent: объединение всех групп с его
+// токеном продукта, иначе объединение групп "*", иначе nil
+func (r *RobotsTxt) Group(userAgent string) *Group {
+	token := productToken(userAgent)
+
+	var matched, wildcard []*Group
+	for _, group := range r.groups {
+		isMatch, isWildcard := false, false
+		for _, agent := range group.Agents {
+			switch {
+			case agent == "*":
+				isWildcard = true
+			case token != "" && strings.EqualFold(productToken(agent), token):
+				isMatch = true
+			}
+		}
+
+		switch {
+		case isMatch:
+			matched = append(matched, group)
+		case isWildcard:
+			wildcard = append(wildcard, group)
+		}
+	}
+
+	if len(matched) == 0 {
+		matched = wildcard
+	}
+	return mergeGroups(matched)
+}
+
+// mergeGroups объединяет правила нескольких групп в одну
+func mergeGroups(groups []*Group) *Group {
+	switch len(groups) {
+	case 0:
+		return nil
+	case 1:
+		return groups[0]
+	}
+
+	merged := &Group{Line: groups[0].Line}
+	for _, group := range groups {
+		merged.Agents = append(merged.Agents, group.Agents...)
+		merged.Rules = append(merged.Rules, group.Rules...)
+		if group.CrawlDelay > merged.CrawlDelay {
+			merged.CrawlDelay = group.CrawlDelay
+		}
+	}
+	return merged
+}
+
+// productToken выделяет токен продукта: "Wget-Go/1.0 (+url)" -> "Wget-Go"
+func productToken(userAgent string) string {
+	end := 0
+	for end < len(userAgent) {
+		c := userAgent[end]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_') {
+			break
+		}
+		end++
+	}
+	return userAgent[:end]
+}
+
+// matchPattern сопоставляет путь с шаблоном правила: "*" соответствует любой
+// последовательности символов, "$" в конце привязывает шаблон к концу пути.
+// Без "$" шаблон сравнивается с началом пути
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	} else {
+		pattern += "*"
+	}
+
+	// Жадное сопоставление с откатом к последней звездочке
+	p, s := 0, 0
+	starP, starS := -1, 0
+	for s < len(path) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			starP, starS = p, s
+			p++
+		case p < len(pattern) && pattern[p] == path[s]:
+			p++
+			s++
+		case starP >= 0:
+			starS++
+			p, s = starP+1, starS
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// normalizePath приводит процентное кодирование к единому виду (RFC 9309, 2.2.2):
+// байты вне ASCII кодируются, закодированные незарезервированные символы
+// раскодируются, шестнадцатеричные цифры приводятся к верхнему регистру
+func normalizePath(path string) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '%' && i+2 < len(path) && isHex(path[i+1]) && isHex(path[i+2]):
+			decoded := unhex(path[i+1])<<4 | unhex(path[i+2])
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(hexDigits[decoded>>4])
+				b.WriteByte(hexDigits[decoded&0x0f])
+			}
+			i += 2
+		case c >= 0x80 || c <= 0x20:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0f])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isUnreserved проверяет, относится ли символ к незарезервированным (RFC 3986)
+func isUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
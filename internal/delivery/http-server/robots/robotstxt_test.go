@@ -0,0 +1,137 @@
+package robots
+
+import (
+	"testing"
+	"time"
+)
+
+const testRobots = `# Общие правила
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Allow: /page
+Disallow: /page
+Disallow: /fish*.php
+Disallow: /caf%c3%a9
+Disallow: /%7ejoe/
+Disallow: /a%2fb
+
+User-agent: Wget-Go
+Disallow: /only-for-wget
+`
+
+func TestDecide(t *testing.T) {
+	robots := Parse([]byte(testRobots))
+
+	for _, tc := range []struct {
+		name      string
+		userAgent string
+		path      string
+		allowed   bool
+		rule      string
+	}{
+		{name: "no rule matches", path: "/index.html", allowed: true},
+		{name: "prefix match", path: "/private/keys", rule: "Disallow: /private"},
+		{name: "longest match wins", path: "/private/public/a.html", allowed: true, rule: "Allow: /private/public"},
+		{name: "Allow wins a tie", path: "/page", allowed: true, rule: "Allow: /page"},
+		{name: "$ anchors the end", path: "/docs/a.pdf", rule: "Disallow: /*.pdf$"},
+		{name: "$ with query", path: "/docs/a.pdf?download=1", allowed: true},
+		{name: "* inside pattern", path: "/fishheads/trout.php", rule: "Disallow: /fish*.php"},
+		{name: "* then prefix", path: "/fish.php?id=1", rule: "Disallow: /fish*.php"},
+		{name: "* needs the rest", path: "/fish.html", allowed: true},
+		{name: "raw UTF-8 matches encoded rule", path: "/caf\xc3\xa9/menu", rule: "Disallow: /caf%c3%a9"},
+		{name: "hex case is ignored", path: "/caf%C3%A9", rule: "Disallow: /caf%c3%a9"},
+		{name: "encoded unreserved is decoded", path: "/~joe/index.html", rule: "Disallow: /%7ejoe/"},
+		{name: "encoded slash stays encoded", path: "/a/b", allowed: true},
+		{name: "encoded slash matches itself", path: "/a%2Fb", rule: "Disallow: /a%2fb"},
+		{name: "robots.txt is always allowed", path: "/robots.txt", allowed: true},
+		{
+			name:      "product token selects own group",
+			userAgent: "Wget-Go/1.0 (+https://example.com/bot)",
+			path:      "/private/keys",
+			allowed:   true,
+		},
+		{
+			name:      "product token is case-insensitive",
+			userAgent: "wget-go/2.0",
+			path:      "/only-for-wget/x",
+			rule:      "Disallow: /only-for-wget",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			userAgent := tc.userAgent
+			if userAgent == "" {
+				userAgent = "Mozilla/5.0"
+			}
+			decision := robots.Decide(userAgent, tc.path)
+			rule := ""
+			if decision.Rule != nil {
+				rule = decision.Rule.String()
+			}
+			if decision.Allowed != tc.allowed || rule != tc.rule {
+				t.Fatalf("Decide(%q) = %v by %q, want %v by %q", tc.path, decision.Allowed, rule, tc.allowed, tc.rule)
+			}
+		})
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	robots := Parse([]byte("Disallow: /orphan\n" +
+		"User-agent: a\nUser-agent: b\nDisallow: /shared\n" +
+		"User-agent: a\nDisallow: /more\n" +
+		"Sitemap: https://example.com/sitemap.xml\n"))
+
+	// Правило до первой группы не действует ни для кого
+	if !robots.IsAllowed("c", "/orphan") {
+		t.Error("rule outside a group applied")
+	}
+	// Группы одного агента объединяются, подряд идущие User-agent делят группу
+	for _, path := range []string{"/shared", "/more"} {
+		if robots.IsAllowed("a", path) {
+			t.Errorf("a is allowed %s", path)
+		}
+	}
+	if robots.IsAllowed("b", "/shared") || !robots.IsAllowed("b", "/more") {
+		t.Error("b does not get exactly the shared group")
+	}
+	if got := robots.Sitemaps(); len(got) != 1 || got[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("Sitemaps = %v", got)
+	}
+}
+
+func TestCrawlDelayParsing(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		rate  bool
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "2", want: 2 * time.Second, ok: true},
+		{value: "0.5", want: 500 * time.Millisecond, ok: true},
+		{value: "-1"},
+		{value: "soon"},
+		{value: "1/5", rate: true, want: 5 * time.Second, ok: true},
+		{value: "1/5s", rate: true, want: 5 * time.Second, ok: true},
+		{value: "10/1m", rate: true, want: 6 * time.Second, ok: true},
+		{value: "1/1h", rate: true, want: time.Hour, ok: true},
+		{value: "1/10 0600-0845", rate: true, want: 10 * time.Second, ok: true},
+		{value: "0/5", rate: true},
+		{value: "5", rate: true},
+		{value: "1/0", rate: true},
+	} {
+		parse, name := parseCrawlDelay, "Crawl-delay"
+		if tc.rate {
+			parse, name = parseRequestRate, "Request-rate"
+		}
+		if got, ok := parse(tc.value); got != tc.want || ok != tc.ok {
+			t.Errorf("%s %q = %v, %v; want %v, %v", name, tc.value, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	// Из нескольких значений группы берется самая длинная пауза
+	robots := Parse([]byte("User-agent: *\nCrawl-delay: 2\nRequest-rate: 1/10\nCrawl-delay: 3\n"))
+	if got := robots.Group("any").CrawlDelay; got != 10*time.Second {
+		t.Fatalf("group crawl delay = %v, want 10s", got)
+	}
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "strings"
+
 // ResourceType определяет тип скачиваемого ресурса
 type ResourceType int
 
@@ -18,15 +20,72 @@ type DownloadTask struct {
 	Depth     int
 	Type      ResourceType
 	ParentURL string
+
+	Mirrors   []string   // Запасные URL того же файла (Metalink)
+	Checksums []Checksum // Ожидаемые контрольные суммы
+	FileName  string     // Путь сохранения относительно каталога вывода
+	Size      int64      // Ожидаемый размер (0 - неизвестен)
+
+	Kind     LinkKind // Как найдена ссылка: переход или ресурс страницы
+	Position int      // Номер ссылки среди ссылок родительской страницы
+	Priority float64  // Приоритет из sitemap (0 - задача не из sitemap)
+	Score    float64  // Оценка для обхода best-first, больше - раньше
+	Seq      int64    // Порядковый номер постановки в очередь
+}
+
+// LinkKind вид ссылки в контенте
+type LinkKind int
+
+const (
+	LinkNavigation LinkKind = iota // Переход на другую страницу: <a>, <area>, <link rel=next>
+	LinkRequisite                  // Ресурс для отображения страницы: изображения, стили, скрипты
+)
+
+// Link ссылка, найденная в контенте
+type Link struct {
+	URL      string
+	Kind     LinkKind
+	NoFollow bool // Ссылка помечена rel="nofollow"
 }
 
 // DownloadResult представляет результат скачивания
 type DownloadResult struct {
-	Task     DownloadTask
-	Content  []byte
-	Links    []string
-	FilePath string
-	Error    error
+	Task      DownloadTask
+	Content   []byte
+	Links     []Link
+	FilePath  string
+	Checksums []Checksum // Успешно проверенные контрольные суммы
+	Error     error
+}
+
+// OutcomeStatus итог обработки URL
+type OutcomeStatus string
+
+const (
+	OutcomeCompleted OutcomeStatus = "completed"
+	OutcomeFailed    OutcomeStatus = "failed"
+)
+
+// URLOutcome итог обработки одного URL за обход
+type URLOutcome struct {
+	URL      string
+	Status   OutcomeStatus
+	FilePath string // Куда сохранен ресурс (пусто - не сохранялся)
+	Error    string
+}
+
+// CrawlState состояние обхода, достаточное для продолжения после прерывания
+type CrawlState struct {
+	Pending  []DownloadTask // Задачи в очереди и в работе на момент сохранения
+	Visited  []string       // Нормализованные URL, уже поставленные в очередь
+	Outcomes []URLOutcome   // Итоги обработанных URL
+}
+
+// Checksum контрольная сумма ресурса
+type Checksum struct {
+	Algorithm string // Алгоритм в нотации RFC 9530: sha-256, sha-512, sha-1, md5
+	Value     string // Значение в нижнем регистре hex
+	Source    string // Откуда взята: Repr-Digest, Digest, файл .sha256, Metalink
 }
 
 // String реализует интерфейс fmt.Stringer для ResourceType
@@ -46,3 +105,33 @@ func (rt ResourceType) String() string {
 		return "Other"
 	}
 }
+
+// ParseResourceType возвращает тип ресурса по его имени (без учета регистра)
+func ParseResourceType(name string) (ResourceType, bool) {
+	for rt := ResourceHTML; rt <= ResourceOther; rt++ {
+		if strings.EqualFold(rt.String(), name) {
+			return rt, true
+		}
+	}
+	return ResourceOther, false
+}
+
+// ResourceTypeFromContentType определяет тип ресурса по Content-Type
+func ResourceTypeFromContentType(contentType string) ResourceType {
+	contentType = strings.ToLower(contentType)
+
+	switch {
+	case strings.Contains(contentType, "text/html"):
+		return ResourceHTML
+	case strings.Contains(contentType, "text/css"):
+		return ResourceCSS
+	case strings.Contains(contentType, "javascript"):
+		return ResourceJavaScript
+	case strings.HasPrefix(contentType, "image/"):
+		return ResourceImage
+	case strings.Contains(contentType, "font"):
+		return ResourceFont
+	default:
+		return ResourceOther
+	}
+}
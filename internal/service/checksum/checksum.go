@@ -0,0 +1,162 @@
+package checksum
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"wget-go/internal/config"
+	httpserver "wget-go/internal/delivery/http-server"
+	"wget-go/internal/domain"
+	"wget-go/internal/service"
+)
+
+// algorithms поддерживаемые алгоритмы, от сильного к слабому
+var algorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha-512", sha512.New},
+	{"sha-256", sha256.New},
+	{"sha-1", sha1.New},
+	{"md5", md5.New},
+}
+
+// sidecarExtensions расширения файлов с контрольными суммами и их алгоритмы
+var sidecarExtensions = []struct {
+	ext       string
+	algorithm string
+}{
+	{".sha256", "sha-256"},
+	{".md5", "md5"},
+}
+
+// Verifier собирает ожидаемые контрольные суммы и сверяет с ними содержимое
+type Verifier struct {
+	client httpserver.Client
+	remote bool // Искать суммы в заголовках ответа и файлах рядом с ресурсом
+}
+
+// New создает проверку целостности. Суммы из заголовков и файлов
+// .sha256/.md5 учитываются только с -verify; суммы из Metalink проверяются всегда
+func New(cfg *config.Config, client httpserver.Client) *Verifier {
+	return &Verifier{
+		client: client,
+		remote: cfg.Verify,
+	}
+}
+
+// Expected возвращает контрольные суммы, заявленные сервером для ресурса
+func (v *Verifier) Expected(ctx context.Context, url string, header http.Header, resourceType domain.ResourceType) []domain.Checksum {
+	if !v.remote {
+		return nil
+	}
+
+	expected := fromHeaders(header)
+	if len(expected) > 0 {
+		return expected
+	}
+
+	// Страницы и стили не публикуют сумм рядом с собой
+	if resourceType == domain.ResourceHTML || resourceType == domain.ResourceCSS {
+		return nil
+	}
+	return v.sidecar(ctx, url)
+}
+
+// sidecar ищет контрольную сумму в файле url.sha256 или url.md5
+func (v *Verifier) sidecar(ctx context.Context, url string) []domain.Checksum {
+	name := url
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	for _, sidecar := range sidecarExtensions {
+		resp, err := v.client.Get(ctx, url+sidecar.ext)
+		if err != nil {
+			continue
+		}
+		if value, ok := parseSidecar(resp.Body, name, sidecar.algorithm); ok {
+			return []domain.Checksum{{
+				Algorithm: sidecar.algorithm,
+				Value:     value,
+				Source:    url + sidecar.ext,
+			}}
+		}
+	}
+	return nil
+}
+
+// Verify вычисляет суммы содержимого за один проход и сравнивает с ожидаемыми
+func (v *Verifier) Verify(expected []domain.Checksum, r io.Reader) error {
+	hashes := make(map[string]hash.Hash)
+	var writers []io.Writer
+	for _, checksum := range expected {
+		if _, exists := hashes[checksum.Algorithm]; exists {
+			continue
+		}
+		newHash := lookup(checksum.Algorithm)
+		if newHash == nil {
+			continue
+		}
+		h := newHash()
+		hashes[checksum.Algorithm] = h
+		writers = append(writers, h)
+	}
+	if len(writers) == 0 {
+		return nil
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return fmt.Errorf("compute checksum: %w", err)
+	}
+
+	for _, checksum := range expected {
+		h, exists := hashes[checksum.Algorithm]
+		if !exists {
+			continue
+		}
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != checksum.Value {
+			return fmt.Errorf("%w: %s from %s is %s, got %s",
+				service.ErrChecksumMismatch, checksum.Algorithm, checksum.Source, checksum.Value, actual)
+		}
+	}
+	return nil
+}
+
+// Supported сообщает, умеет ли проверка вычислять алгоритм
+func Supported(algorithm string) bool {
+	return lookup(algorithm) != nil
+}
+
+// lookup возвращает конструктор хэша по имени алгоритма
+func lookup(algorithm string) func() hash.Hash {
+	for _, alg := range algorithms {
+		if alg.name == algorithm {
+			return alg.new
+		}
+	}
+	return nil
+}
+
+// normalizeAlgorithm приводит имена алгоритмов из разных источников к нотации RFC 9530
+func normalizeAlgorithm(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "sha", "sha1":
+		return "sha-1"
+	case "sha256":
+		return "sha-256"
+	case "sha512":
+		return "sha-512"
+	}
+	return name
+}
@@ -0,0 +1,229 @@
+package checksum
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"wget-go/internal/config"
+	httpserver "wget-go/internal/delivery/http-server"
+	"wget-go/internal/domain"
+	"wget-go/internal/service"
+)
+
+const content = "wget-go checksum test\n"
+
+var (
+	sha256Sum = sha256.Sum256([]byte(content))
+	sha512Sum = sha512.Sum512([]byte(content))
+	md5Sum    = md5.Sum([]byte(content))
+
+	sha256Hex = hex.EncodeToString(sha256Sum[:])
+	sha512Hex = hex.EncodeToString(sha512Sum[:])
+	md5Hex    = hex.EncodeToString(md5Sum[:])
+
+	sha256B64 = base64.StdEncoding.EncodeToString(sha256Sum[:])
+	sha512B64 = base64.StdEncoding.EncodeToString(sha512Sum[:])
+	md5B64    = base64.StdEncoding.EncodeToString(md5Sum[:])
+)
+
+func TestFromHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		want   []domain.Checksum
+	}{
+		{
+			name:   "Repr-Digest sorted strongest first",
+			header: http.Header{"Repr-Digest": {"sha-256=:" + sha256B64 + ":, sha-512=:" + sha512B64 + ":"}},
+			want: []domain.Checksum{
+				{Algorithm: "sha-512", Value: sha512Hex, Source: "Repr-Digest"},
+				{Algorithm: "sha-256", Value: sha256Hex, Source: "Repr-Digest"},
+			},
+		},
+		{
+			name:   "Content-Digest with parameters",
+			header: http.Header{"Content-Digest": {"sha-256=:" + sha256B64 + ":;note=1"}},
+			want:   []domain.Checksum{{Algorithm: "sha-256", Value: sha256Hex, Source: "Content-Digest"}},
+		},
+		{
+			name:   "legacy Digest alone",
+			header: http.Header{"Digest": {"MD5=" + md5B64 + ", SHA-256=" + sha256B64}},
+			want: []domain.Checksum{
+				{Algorithm: "sha-256", Value: sha256Hex, Source: "Digest"},
+				{Algorithm: "md5", Value: md5Hex, Source: "Digest"},
+			},
+		},
+		{
+			name: "legacy Digest ignored next to Repr-Digest",
+			header: http.Header{
+				"Repr-Digest": {"sha-256=:" + sha256B64 + ":"},
+				"Digest":      {"MD5=" + md5B64},
+			},
+			want: []domain.Checksum{{Algorithm: "sha-256", Value: sha256Hex, Source: "Repr-Digest"}},
+		},
+		{
+			name:   "unsupported algorithm skipped",
+			header: http.Header{"Repr-Digest": {"unixsum=:AAA=:, sha-256=:" + sha256B64 + ":"}},
+			want:   []domain.Checksum{{Algorithm: "sha-256", Value: sha256Hex, Source: "Repr-Digest"}},
+		},
+		{
+			name:   "value without colons skipped",
+			header: http.Header{"Repr-Digest": {"sha-256=" + sha256B64}},
+		},
+		{
+			name:   "invalid base64 skipped",
+			header: http.Header{"Digest": {"SHA-256=not base64!"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fromHeaders(tc.header); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("fromHeaders = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSidecar(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		content   string
+		algorithm string
+		want      string
+		found     bool
+	}{
+		{
+			name:      "coreutils text mode",
+			content:   sha256Hex + "  other.iso\n" + sha256Hex[:63] + "0  file.iso\n",
+			algorithm: "sha-256",
+			want:      sha256Hex[:63] + "0",
+			found:     true,
+		},
+		{
+			name:      "coreutils binary mode with path",
+			content:   sha256Hex + " *dist/file.iso\n",
+			algorithm: "sha-256",
+			want:      sha256Hex,
+			found:     true,
+		},
+		{
+			name:      "BSD format",
+			content:   "MD5 (file.iso) = " + strings.ToUpper(md5Hex) + "\n",
+			algorithm: "md5",
+			want:      md5Hex,
+			found:     true,
+		},
+		{
+			name:      "single value with comment",
+			content:   "# release checksum\n" + sha256Hex + "\n",
+			algorithm: "sha-256",
+			want:      sha256Hex,
+			found:     true,
+		},
+		{
+			name:      "single line for another file",
+			content:   sha256Hex + "  file.iso.asc\n",
+			algorithm: "sha-256",
+			want:      sha256Hex,
+			found:     true,
+		},
+		{
+			name:      "several files without a match",
+			content:   sha256Hex + "  a.iso\n" + sha256Hex + "  b.iso\n",
+			algorithm: "sha-256",
+		},
+		{
+			name:      "wrong length for algorithm",
+			content:   md5Hex + "  file.iso\n",
+			algorithm: "sha-256",
+		},
+		{
+			name:      "HTML error page",
+			content:   "<html><body>Not Found</body></html>",
+			algorithm: "sha-256",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, found := parseSidecar([]byte(tc.content), "file.iso", tc.algorithm)
+			if got != tc.want || found != tc.found {
+				t.Fatalf("parseSidecar = %q, %v; want %q, %v", got, found, tc.want, tc.found)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	v := New(config.Default(), nil)
+
+	matching := []domain.Checksum{
+		{Algorithm: "sha-512", Value: sha512Hex, Source: "Repr-Digest"},
+		{Algorithm: "md5", Value: md5Hex, Source: "Digest"},
+		{Algorithm: "crc32c", Value: "00000000", Source: "metalink"},
+	}
+	if err := v.Verify(matching, strings.NewReader(content)); err != nil {
+		t.Fatalf("matching checksums: %v", err)
+	}
+
+	mismatched := []domain.Checksum{{Algorithm: "sha-256", Value: sha256Hex, Source: "file.iso.sha256"}}
+	err := v.Verify(mismatched, strings.NewReader(content+"tampered"))
+	if !errors.Is(err, service.ErrChecksumMismatch) {
+		t.Fatalf("tampered content: err = %v, want ErrChecksumMismatch", err)
+	}
+	if !strings.Contains(err.Error(), "file.iso.sha256") {
+		t.Errorf("error %q does not name the checksum source", err)
+	}
+}
+
+// sidecarClient отдает содержимое по точному URL и ошибку для остальных
+type sidecarClient map[string]string
+
+func (c sidecarClient) Get(_ context.Context, url string) (*httpserver.Response, error) {
+	body, ok := c[url]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return &httpserver.Response{URL: url, StatusCode: http.StatusOK, Body: []byte(body)}, nil
+}
+
+func (c sidecarClient) Head(ctx context.Context, url string) (*httpserver.Response, error) {
+	return c.Get(ctx, url)
+}
+
+func TestExpected(t *testing.T) {
+	const fileURL = "https://example.com/dist/file.iso"
+	client := sidecarClient{fileURL + ".md5": md5Hex + "  file.iso\n"}
+
+	cfg := config.Default()
+	cfg.Verify = true
+	v := New(cfg, client)
+	ctx := context.Background()
+
+	// Без заголовков сумма берется из файла рядом: .sha256 нет, есть .md5
+	want := []domain.Checksum{{Algorithm: "md5", Value: md5Hex, Source: fileURL + ".md5"}}
+	if got := v.Expected(ctx, fileURL, http.Header{}, domain.ResourceOther); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sidecar checksum = %+v, want %+v", got, want)
+	}
+
+	// Заголовок ответа важнее файла рядом
+	header := http.Header{"Repr-Digest": {"sha-256=:" + sha256B64 + ":"}}
+	if got := v.Expected(ctx, fileURL, header, domain.ResourceOther); len(got) != 1 || got[0].Source != "Repr-Digest" {
+		t.Fatalf("header checksum = %+v", got)
+	}
+
+	// Для страниц файлы рядом не запрашиваются
+	if got := v.Expected(ctx, fileURL, http.Header{}, domain.ResourceHTML); got != nil {
+		t.Fatalf("HTML page checksum = %+v, want none", got)
+	}
+
+	// Без -verify суммы сервера не учитываются
+	if got := New(config.Default(), client).Expected(ctx, fileURL, header, domain.ResourceOther); got != nil {
+		t.Fatalf("checksums without -verify: %+v", got)
+	}
+}
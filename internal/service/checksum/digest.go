@@ -0,0 +1,100 @@
+package checksum
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"wget-go/internal/domain"
+)
+
+// fromHeaders извлекает суммы из Repr-Digest, Content-Digest (RFC 9530)
+// и устаревшего Digest (RFC 3230). Суммы отсортированы от сильной к слабой
+func fromHeaders(header http.Header) []domain.Checksum {
+	var checksums []domain.Checksum
+
+	for _, name := range []string{"Repr-Digest", "Content-Digest"} {
+		for _, value := range header.Values(name) {
+			checksums = append(checksums, parseStructuredDigest(value, name)...)
+		}
+	}
+	// Digest учитываем, только если сервер не прислал новых заголовков
+	if len(checksums) == 0 {
+		for _, value := range header.Values("Digest") {
+			checksums = append(checksums, parseLegacyDigest(value)...)
+		}
+	}
+
+	sort.SliceStable(checksums, func(i, j int) bool {
+		return strength(checksums[i].Algorithm) < strength(checksums[j].Algorithm)
+	})
+	return checksums
+}
+
+// parseStructuredDigest разбирает словарь вида sha-256=:base64:, sha-512=:base64:
+func parseStructuredDigest(value, source string) []domain.Checksum {
+	var checksums []domain.Checksum
+	for _, member := range strings.Split(value, ",") {
+		name, encoded, found := strings.Cut(strings.TrimSpace(member), "=")
+		if !found {
+			continue
+		}
+		// Параметры элемента словаря не используются
+		if i := strings.IndexByte(encoded, ';'); i >= 0 {
+			encoded = encoded[:i]
+		}
+		encoded = strings.TrimSpace(encoded)
+		if len(encoded) < 2 || encoded[0] != ':' || encoded[len(encoded)-1] != ':' {
+			continue
+		}
+
+		if checksum, ok := newChecksum(name, encoded[1:len(encoded)-1], source); ok {
+			checksums = append(checksums, checksum)
+		}
+	}
+	return checksums
+}
+
+// parseLegacyDigest разбирает список вида SHA-256=base64, MD5=base64
+func parseLegacyDigest(value string) []domain.Checksum {
+	var checksums []domain.Checksum
+	for _, member := range strings.Split(value, ",") {
+		name, encoded, found := strings.Cut(strings.TrimSpace(member), "=")
+		if !found {
+			continue
+		}
+		if checksum, ok := newChecksum(name, strings.TrimSpace(encoded), "Digest"); ok {
+			checksums = append(checksums, checksum)
+		}
+	}
+	return checksums
+}
+
+// newChecksum создает сумму из base64 значения поддерживаемого алгоритма
+func newChecksum(name, encoded, source string) (domain.Checksum, bool) {
+	algorithm := normalizeAlgorithm(name)
+	if !Supported(algorithm) {
+		return domain.Checksum{}, false
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return domain.Checksum{}, false
+	}
+	return domain.Checksum{
+		Algorithm: algorithm,
+		Value:     hex.EncodeToString(raw),
+		Source:    source,
+	}, true
+}
+
+// strength возвращает позицию алгоритма в списке от сильного к слабому
+func strength(algorithm string) int {
+	for i, alg := range algorithms {
+		if alg.name == algorithm {
+			return i
+		}
+	}
+	return len(algorithms)
+}
@@ -0,0 +1,73 @@
+package checksum
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"strings"
+)
+
+// parseSidecar ищет сумму файла name в содержимом .sha256/.md5 файла.
+// Поддерживаются форматы coreutils ("hex  name", "hex *name"),
+// BSD ("SHA256 (name) = hex") и файл из одной суммы
+func parseSidecar(content []byte, name, algorithm string) (string, bool) {
+	var single string
+	lines := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines++
+
+		value, file := splitSidecarLine(line)
+		if !validHex(value, algorithm) {
+			continue
+		}
+		if file == name {
+			return value, true
+		}
+		if file == "" || lines == 1 {
+			single = value
+		}
+	}
+
+	// Файл с единственной суммой относится к ресурсу, рядом с которым лежит
+	if lines == 1 && single != "" {
+		return single, true
+	}
+	return "", false
+}
+
+// splitSidecarLine выделяет из строки сумму и имя файла
+func splitSidecarLine(line string) (value, file string) {
+	// BSD формат: ALG (name) = hex
+	if open := strings.Index(line, " ("); open >= 0 {
+		if closeIdx := strings.LastIndex(line, ") = "); closeIdx > open {
+			return strings.ToLower(line[closeIdx+4:]), line[open+2 : closeIdx]
+		}
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	value = strings.ToLower(fields[0])
+	if len(fields) > 1 {
+		file = strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		file = file[strings.LastIndex(file, "/")+1:]
+	}
+	return value, file
+}
+
+// validHex проверяет, что значение похоже на сумму алгоритма
+func validHex(value, algorithm string) bool {
+	newHash := lookup(algorithm)
+	if newHash == nil {
+		return false
+	}
+	raw, err := hex.DecodeString(value)
+	return err == nil && len(raw) == newHash().Size()
+}
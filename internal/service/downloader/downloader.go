@@ -1,98 +1,466 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"wget-go/internal/config"
 	httpserver "wget-go/internal/delivery/http-server"
 	"wget-go/internal/domain"
 	"wget-go/internal/service"
+	"wget-go/internal/service/robotsmeta"
 	"wget-go/internal/storage"
+	"wget-go/pkg/utils"
 )
 
 // WebDownloader реализует сервис загрузки
 type WebDownloader struct {
+	config       *config.Config
 	httpClient   httpserver.Client
 	fileManager  storage.FileManager
 	pathResolver storage.PathResolver
 	linkRewriter storage.LinkRewriter
 	extractor    service.Extractor
+	quota        service.Quota
+	segmented    service.SegmentedFetcher
+	verifier     service.Verifier
+	skipLog      storage.SkipLog
+
+	mu       sync.Mutex
+	pages    []savedPage       // Сохраненные HTML страницы (только с -content-disposition)
+	pagesDir string            // Временный каталог с исходным HTML страниц
+	renames  map[string]string // URL ресурса -> путь по Content-Disposition
+}
+
+// savedPage сохраненная страница для исправления ссылок на ресурсы,
+// переименованные после ее записи. Исходный HTML лежит на диске, чтобы
+// память не росла с числом страниц
+type savedPage struct {
+	url      string
+	path     string
+	original string // Файл с исходным HTML во временном каталоге
 }
 
 // New создает новый загрузчик
 func New(
+	config *config.Config,
 	httpClient httpserver.Client,
 	fileManager storage.FileManager,
 	pathResolver storage.PathResolver,
 	linkRewriter storage.LinkRewriter,
 	extractor service.Extractor,
+	quota service.Quota,
+	segmented service.SegmentedFetcher,
+	verifier service.Verifier,
+	skipLog storage.SkipLog,
 ) *WebDownloader {
 	return &WebDownloader{
+		config:       config,
 		httpClient:   httpClient,
 		fileManager:  fileManager,
 		pathResolver: pathResolver,
 		linkRewriter: linkRewriter,
 		extractor:    extractor,
+		quota:        quota,
+		segmented:    segmented,
+		verifier:     verifier,
+		skipLog:      skipLog,
+		renames:      make(map[string]string),
 	}
 }
 
-// Download загружает ресурс по URL
+// Download загружает ресурс по URL, а при неудаче перебирает запасные зеркала задачи
 func (d *WebDownloader) Download(ctx context.Context, task domain.DownloadTask) (domain.DownloadResult, error) {
+	result, err := d.download(ctx, task)
+	for _, mirror := range task.Mirrors {
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		log.Printf("Download of %s failed: %v, trying mirror %s", result.Task.URL, err, mirror)
+
+		attempt := task
+		attempt.URL = mirror
+		result, err = d.download(ctx, attempt)
+	}
+	return result, err
+}
+
+// download загружает ресурс с task.URL
+func (d *WebDownloader) download(ctx context.Context, task domain.DownloadTask) (domain.DownloadResult, error) {
 	result := domain.DownloadResult{Task: task}
 
 	// определяем тип ресурса через HEAD запрос
-	resourceType, err := d.determineResourceTypeWithHead(ctx, task.URL)
-	if err != nil {
-		// Если HEAD не удался, продолжаем с GET
-		resourceType = d.determineResourceTypeByURL(task.URL)
+	head, err := d.httpClient.Head(ctx, task.URL)
+	resourceType := d.determineResourceTypeByURL(task.URL)
+	if err == nil {
+		resourceType = domain.ResourceTypeFromContentType(head.ContentType)
+
+		// Большие файлы качаем параллельными диапазонами, если сервер это позволяет
+		if result, handled, err := d.downloadSegmented(ctx, task, resourceType, head); handled {
+			return result, err
+		}
 	}
 
-	content, contentType, err := d.httpClient.Get(ctx, task.URL)
+	resp, err := d.httpClient.Get(ctx, task.URL)
 	if err != nil {
 		result.Error = err
 		return result, err
 	}
 
+	// Каталог без слеша на конце (file:///site, редирект /docs на /docs/)
+	// обрабатывается по URL со слешем: от него разрешаются относительные
+	// ссылки страницы и строится путь site/index.html
+	if isDirectoryOf(task.URL, resp.URL) {
+		task.URL = resp.URL
+	}
+
+	if d.quota != nil {
+		d.quota.Add(int64(len(resp.Body)))
+	}
+
+	if d.config.ContentDisposition && task.FileName == "" {
+		if err := d.applyContentDisposition(task.URL, resp.Header.Get("Content-Disposition")); err != nil {
+			return result, err
+		}
+	}
+
 	// Уточняем тип ресурса на основе Content-Type
-	finalResourceType := d.refineResourceType(resourceType, contentType)
+	finalResourceType := d.refineResourceType(resourceType, resp.ContentType)
+
+	if err := d.checkSize(finalResourceType, int64(len(resp.Body))); err != nil {
+		return result, err
+	}
+
+	// Целостность проверяем до перезаписи ссылок, по исходным байтам
+	checksums, err := d.verify(ctx, task, resp.Header, finalResourceType, int64(len(resp.Body)), bytes.NewReader(resp.Body))
+	if err != nil {
+		return result, err
+	}
+
+	directives := d.robotsDirectives(resp.Header, finalResourceType, resp.Body)
 
 	switch finalResourceType {
 	case domain.ResourceHTML:
-		return d.processHTML(task, content)
+		result, err = d.processHTML(task, resp.Body, directives)
 	case domain.ResourceCSS:
-		return d.processCSS(task, content)
+		result, err = d.processCSS(task, resp.Body, directives)
 	default:
-		return d.processBinary(task, content)
+		result, err = d.processBinary(task, resp.Body, directives)
+	}
+	result.Checksums = checksums
+	return result, err
+}
+
+// isDirectoryOf проверяет, что ответ пришел по URL каталога: исходный
+// URL со слешем на конце пути
+func isDirectoryOf(requestURL, responseURL string) bool {
+	requestURL, _, _ = strings.Cut(requestURL, "#")
+	return responseURL == requestURL+"/"
+}
+
+// robotsDirectives собирает директивы X-Robots-Tag и meta robots страницы,
+// если включено соблюдение robots
+func (d *WebDownloader) robotsDirectives(header http.Header, resourceType domain.ResourceType, content []byte) robotsmeta.Directives {
+	if !d.config.RespectRobots {
+		return robotsmeta.Directives{}
+	}
+
+	directives := robotsmeta.FromHeader(header, d.config.UserAgent)
+	if resourceType == domain.ResourceHTML {
+		directives = directives.Merge(robotsmeta.FromHTML(content, d.config.UserAgent))
+	}
+	return directives
+}
+
+// followable убирает ссылки, по которым запрещено переходить: все переходы
+// страницы с nofollow и ссылки rel="nofollow". Ресурсы страницы остаются
+func (d *WebDownloader) followable(task domain.DownloadTask, links []domain.Link, directives robotsmeta.Directives) []domain.Link {
+	if !d.config.RespectRobots {
+		return links
 	}
+
+	filtered := links[:0]
+	for _, link := range links {
+		var reason string
+		switch {
+		case link.Kind == domain.LinkRequisite:
+		case directives.NoFollow:
+			reason = "nofollow " + strings.Join(directives.Sources, ", ") + " on " + task.URL
+		case link.NoFollow:
+			reason = `rel="nofollow" on ` + task.URL
+		}
+
+		if reason == "" {
+			filtered = append(filtered, link)
+			continue
+		}
+		if absoluteURL, err := d.pathResolver.ResolveAbsoluteURL(task.URL, link.URL); err == nil {
+			d.recordSkip(absoluteURL, reason)
+		}
+	}
+	return filtered
+}
+
+// keepCopy проверяет, можно ли сохранить ресурс, и записывает отказ из-за noindex/noarchive
+func (d *WebDownloader) keepCopy(task domain.DownloadTask, directives robotsmeta.Directives) bool {
+	if !directives.DontSave() {
+		return true
+	}
+	d.recordSkip(task.URL, "not saved: "+directives.Reason())
+	return false
+}
+
+// recordSkip записывает решение в журнал пропусков
+func (d *WebDownloader) recordSkip(url, reason string) {
+	if d.skipLog != nil {
+		d.skipLog.Record(url, reason)
+	}
+}
+
+// verify сверяет размер и контрольные суммы ресурса с ожидаемыми и
+// возвращает успешно проверенные суммы
+func (d *WebDownloader) verify(
+	ctx context.Context,
+	task domain.DownloadTask,
+	header http.Header,
+	resourceType domain.ResourceType,
+	size int64,
+	content io.Reader,
+) ([]domain.Checksum, error) {
+	if task.Size > 0 && size != task.Size {
+		return nil, fmt.Errorf("%w: size %d, expected %d", service.ErrChecksumMismatch, size, task.Size)
+	}
+	if d.verifier == nil {
+		return nil, nil
+	}
+
+	expected := append([]domain.Checksum(nil), task.Checksums...)
+	expected = append(expected, d.verifier.Expected(ctx, task.URL, header, resourceType)...)
+	if len(expected) == 0 {
+		return nil, nil
+	}
+
+	if err := d.verifier.Verify(expected, content); err != nil {
+		return nil, err
+	}
+	return expected, nil
+}
+
+// localPath возвращает путь сохранения: явное имя задачи или путь по URL
+func (d *WebDownloader) localPath(task domain.DownloadTask) (string, error) {
+	if task.FileName != "" {
+		return filepath.Join(d.config.OutputDir, task.FileName), nil
+	}
+	return d.pathResolver.URLToLocalPath(task.URL)
 }
 
-// determineResourceTypeWithHead пытается определить тип ресурса через HEAD запрос
-func (d *WebDownloader) determineResourceTypeWithHead(ctx context.Context, url string) (domain.ResourceType, error) {
-	contentType, err := d.httpClient.Head(ctx, url)
+// checkSize проверяет размер ресурса по лимиту для его типа
+func (d *WebDownloader) checkSize(resourceType domain.ResourceType, size int64) error {
+	if d.quota == nil {
+		return nil
+	}
+
+	limit := d.quota.LimitFor(resourceType)
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %s of %d bytes > %d", httpserver.ErrTooLarge, resourceType, size, limit)
+	}
+	return nil
+}
+
+// downloadSegmented скачивает ресурс диапазонами прямо в файл. handled равно
+// false, если ресурс для этого не подходит и нужно выполнить обычный GET
+func (d *WebDownloader) downloadSegmented(
+	ctx context.Context,
+	task domain.DownloadTask,
+	resourceType domain.ResourceType,
+	head *httpserver.Response,
+) (result domain.DownloadResult, handled bool, err error) {
+	result = domain.DownloadResult{Task: task}
+
+	// HTML и CSS нужны в памяти для извлечения и перезаписи ссылок
+	if d.segmented == nil || resourceType == domain.ResourceHTML || resourceType == domain.ResourceCSS {
+		return result, false, nil
+	}
+	// Диапазоны пишутся сразу в файл; ресурс, который нельзя хранить,
+	// проходит обычный путь и не сохраняется
+	if d.robotsDirectives(head.Header, resourceType, nil).DontSave() {
+		return result, false, nil
+	}
+	if head.Header.Get("Accept-Ranges") != "bytes" {
+		return result, false, nil
+	}
+	if encoding := head.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return result, false, nil
+	}
+
+	size, err := strconv.ParseInt(head.Header.Get("Content-Length"), 10, 64)
+	if err != nil || size <= 0 || d.segmented.Segments(size) < 2 {
+		return result, false, nil
+	}
+
+	if err := d.checkSize(resourceType, size); err != nil {
+		return result, true, err
+	}
+
+	if d.config.ContentDisposition && task.FileName == "" {
+		if err := d.applyContentDisposition(task.URL, head.Header.Get("Content-Disposition")); err != nil {
+			return result, true, err
+		}
+	}
+
+	localPath, err := d.localPath(task)
 	if err != nil {
-		return domain.ResourceOther, err
+		return result, true, err
 	}
-	return d.determineResourceTypeByContentType(contentType), nil
+
+	log.Printf("Saving to: %s", localPath)
+
+	if err := d.segmented.Fetch(ctx, head.URL, localPath, size, head.Header); err != nil {
+		if errors.Is(err, httpserver.ErrRangeIgnored) || errors.Is(err, httpserver.ErrRangeNotSupported) {
+			log.Printf("Segmented download of %s not possible, falling back to single request: %v", task.URL, err)
+			return result, false, nil
+		}
+		return result, true, err
+	}
+
+	if d.quota != nil {
+		d.quota.Add(size)
+	}
+
+	checksums, err := d.verifyFile(ctx, task, head.Header, resourceType, size, localPath)
+	if err != nil {
+		os.Remove(localPath)
+		return result, true, err
+	}
+
+	result.FilePath = localPath
+	result.Checksums = checksums
+	return result, true, nil
 }
 
-// determineResourceTypeByContentType определяет тип по Content-Type
-func (d *WebDownloader) determineResourceTypeByContentType(contentType string) domain.ResourceType {
-	contentType = strings.ToLower(contentType)
+// verifyFile проверяет целостность файла, собранного на диске
+func (d *WebDownloader) verifyFile(
+	ctx context.Context,
+	task domain.DownloadTask,
+	header http.Header,
+	resourceType domain.ResourceType,
+	size int64,
+	path string,
+) ([]domain.Checksum, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-	switch {
-	case strings.Contains(contentType, "text/html"):
-		return domain.ResourceHTML
-	case strings.Contains(contentType, "text/css"):
-		return domain.ResourceCSS
-	case strings.Contains(contentType, "javascript"):
-		return domain.ResourceJavaScript
-	case strings.HasPrefix(contentType, "image/"):
-		return domain.ResourceImage
-	case strings.Contains(contentType, "font"):
-		return domain.ResourceFont
-	default:
-		return domain.ResourceOther
+	return d.verify(ctx, task, header, resourceType, size, file)
+}
+
+// applyContentDisposition сохраняет ресурс под именем из Content-Disposition
+func (d *WebDownloader) applyContentDisposition(url, header string) error {
+	filename, ok := utils.FilenameFromDisposition(header)
+	if !ok {
+		return nil
 	}
+
+	urlPath, err := d.pathResolver.URLToLocalPath(url)
+	if err != nil {
+		return err
+	}
+
+	// Ключ - URL без фрагмента: разные URL (download?id=1 и download?id=2)
+	// могут иметь один путь по умолчанию
+	key, _, _ := strings.Cut(url, "#")
+
+	localPath, err := d.pathResolver.UseFilename(url, filename)
+	if err != nil {
+		return fmt.Errorf("apply content-disposition: %w", err)
+	}
+
+	if localPath != urlPath {
+		log.Printf("Content-Disposition: saving %s as %s", url, filepath.Base(localPath))
+
+		d.mu.Lock()
+		d.renames[key] = localPath
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// FixRenamedLinks исправляет ссылки в сохраненных страницах на ресурсы,
+// переименованные после того, как страница была записана. Ссылки заново
+// разрешаются по исходному HTML страницы, поэтому ресурсы с одинаковым
+// путем по умолчанию получают каждый свой файл
+func (d *WebDownloader) FixRenamedLinks() error {
+	d.mu.Lock()
+	pages := append([]savedPage(nil), d.pages...)
+	renames := make(map[string]string, len(d.renames))
+	for from, to := range d.renames {
+		renames[from] = to
+	}
+	pagesDir := d.pagesDir
+	d.mu.Unlock()
+
+	if pagesDir != "" {
+		defer os.RemoveAll(pagesDir)
+	}
+	if len(renames) == 0 {
+		return nil
+	}
+
+	for _, page := range pages {
+		original, err := os.ReadFile(page.original)
+		if err != nil {
+			return fmt.Errorf("load original of %s: %w", page.path, err)
+		}
+		content, err := d.fileManager.Load(page.path)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", page.path, err)
+		}
+
+		fixed := d.linkRewriter.RewriteRenamed(original, page.url, page.path, renames)
+		if bytes.Equal(fixed, content) {
+			continue
+		}
+
+		if err := d.fileManager.Save(page.path, fixed); err != nil {
+			return fmt.Errorf("save %s: %w", page.path, err)
+		}
+	}
+	return nil
+}
+
+// keepOriginal записывает исходный HTML страницы во временный каталог для
+// FixRenamedLinks. Без него ссылки страницы на переименованные ресурсы
+// останутся прежними
+func (d *WebDownloader) keepOriginal(pageURL, localPath string, content []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pagesDir == "" {
+		dir, err := os.MkdirTemp("", "wget-go-pages-")
+		if err != nil {
+			log.Printf("Cannot keep %s to fix renamed links: %v", pageURL, err)
+			return
+		}
+		d.pagesDir = dir
+	}
+
+	original := filepath.Join(d.pagesDir, fmt.Sprintf("page-%06d.html", len(d.pages)))
+	if err := os.WriteFile(original, content, 0644); err != nil {
+		log.Printf("Cannot keep %s to fix renamed links: %v", pageURL, err)
+		return
+	}
+	d.pages = append(d.pages, savedPage{url: pageURL, path: localPath, original: original})
 }
 
 // determineResourceTypeByURL определяет тип по расширению файла
@@ -120,7 +488,7 @@ func (d *WebDownloader) determineResourceTypeByURL(url string) domain.ResourceTy
 
 // refineResourceType уточняет тип ресурса на основе Content-Type
 func (d *WebDownloader) refineResourceType(currentType domain.ResourceType, contentType string) domain.ResourceType {
-	contentTypeBased := d.determineResourceTypeByContentType(contentType)
+	contentTypeBased := domain.ResourceTypeFromContentType(contentType)
 
 	// Если тип по Content-Type более специфичный, используем его
 	if contentTypeBased != domain.ResourceOther {
@@ -131,7 +499,7 @@ func (d *WebDownloader) refineResourceType(currentType domain.ResourceType, cont
 
 // processHTML обрабатывает HTML контент
 // В processHTML метод добавьте логирование:
-func (d *WebDownloader) processHTML(task domain.DownloadTask, content []byte) (domain.DownloadResult, error) {
+func (d *WebDownloader) processHTML(task domain.DownloadTask, content []byte, directives robotsmeta.Directives) (domain.DownloadResult, error) {
 	result := domain.DownloadResult{Task: task}
 
 	// Извлекаем ссылки
@@ -139,11 +507,16 @@ func (d *WebDownloader) processHTML(task domain.DownloadTask, content []byte) (d
 	if err != nil {
 		return result, err
 	}
+	links = d.followable(task, links, directives)
 	result.Links = links
 
 	log.Printf("Found %d links in %s", len(links), task.URL)
 	for i, link := range links {
-		log.Printf("  Link %d: %s", i+1, link)
+		log.Printf("  Link %d: %s", i+1, link.URL)
+	}
+
+	if !d.keepCopy(task, directives) {
+		return result, nil
 	}
 
 	// Перезаписываем ссылки
@@ -153,7 +526,7 @@ func (d *WebDownloader) processHTML(task domain.DownloadTask, content []byte) (d
 	}
 
 	// Сохраняем файл
-	localPath, err := d.pathResolver.URLToLocalPath(task.URL)
+	localPath, err := d.localPath(task)
 	if err != nil {
 		return result, err
 	}
@@ -164,13 +537,19 @@ func (d *WebDownloader) processHTML(task domain.DownloadTask, content []byte) (d
 		return result, err
 	}
 
+	// Переименования бывают только с -content-disposition, иначе исходный
+	// HTML хранить незачем
+	if d.config.ContentDisposition {
+		d.keepOriginal(task.URL, localPath, content)
+	}
+
 	result.FilePath = localPath
 	result.Content = rewrittenContent
 	return result, nil
 }
 
 // processCSS обрабатывает CSS контент
-func (d *WebDownloader) processCSS(task domain.DownloadTask, content []byte) (domain.DownloadResult, error) {
+func (d *WebDownloader) processCSS(task domain.DownloadTask, content []byte, directives robotsmeta.Directives) (domain.DownloadResult, error) {
 	result := domain.DownloadResult{Task: task}
 
 	links, err := d.extractor.ExtractLinks(content, task.URL, "text/css")
@@ -179,12 +558,16 @@ func (d *WebDownloader) processCSS(task domain.DownloadTask, content []byte) (do
 	}
 	result.Links = links
 
+	if !d.keepCopy(task, directives) {
+		return result, nil
+	}
+
 	rewrittenContent, err := d.linkRewriter.RewriteCSS(content, task.URL)
 	if err != nil {
 		return result, err
 	}
 
-	localPath, err := d.pathResolver.URLToLocalPath(task.URL)
+	localPath, err := d.localPath(task)
 	if err != nil {
 		return result, err
 	}
@@ -201,10 +584,14 @@ func (d *WebDownloader) processCSS(task domain.DownloadTask, content []byte) (do
 }
 
 // processBinary обрабатывает бинарный контент
-func (d *WebDownloader) processBinary(task domain.DownloadTask, content []byte) (domain.DownloadResult, error) {
+func (d *WebDownloader) processBinary(task domain.DownloadTask, content []byte, directives robotsmeta.Directives) (domain.DownloadResult, error) {
 	result := domain.DownloadResult{Task: task}
 
-	localPath, err := d.pathResolver.URLToLocalPath(task.URL)
+	if !d.keepCopy(task, directives) {
+		return result, nil
+	}
+
+	localPath, err := d.localPath(task)
 	if err != nil {
 		return result, err
 	}
@@ -3,6 +3,7 @@ package extractor
 import (
 	"regexp"
 	"strings"
+	"wget-go/internal/domain"
 	"wget-go/internal/service"
 )
 
@@ -21,7 +22,7 @@ func New(htmlParser service.HTMLParser) *LinkExtractor {
 }
 
 // ExtractLinks извлекает ссылки из контента
-func (e *LinkExtractor) ExtractLinks(content []byte, baseURL string, contentType string) ([]string, error) {
+func (e *LinkExtractor) ExtractLinks(content []byte, baseURL string, contentType string) ([]domain.Link, error) {
 	if strings.Contains(contentType, "text/html") {
 		return e.htmlParser.Parse(content, baseURL)
 	}
@@ -33,16 +34,16 @@ func (e *LinkExtractor) ExtractLinks(content []byte, baseURL string, contentType
 	return nil, nil
 }
 
-// extractFromCSS извлекает ссылки из CSS
-func (e *LinkExtractor) extractFromCSS(cssContent []byte) []string {
-	var links []string
+// extractFromCSS извлекает ссылки из CSS. Все они нужны для отображения страницы
+func (e *LinkExtractor) extractFromCSS(cssContent []byte) []domain.Link {
+	var links []domain.Link
 	content := string(cssContent)
 
 	// ищем url в css
 	matches := e.cssURLRegex.FindAllStringSubmatch(content, -1)
 	for _, match := range matches {
 		if len(match) > 1 && match[1] != "" {
-			links = append(links, match[1])
+			links = append(links, domain.Link{URL: match[1], Kind: domain.LinkRequisite})
 		}
 	}
 
@@ -51,7 +52,7 @@ func (e *LinkExtractor) extractFromCSS(cssContent []byte) []string {
 	importMatches := importRegex.FindAllStringSubmatch(content, -1)
 	for _, match := range importMatches {
 		if len(match) > 1 && match[1] != "" {
-			links = append(links, match[1])
+			links = append(links, domain.Link{URL: match[1], Kind: domain.LinkRequisite})
 		}
 	}
 
@@ -0,0 +1,309 @@
+package frontier
+
+import (
+	"container/heap"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+// Frontier очередь задач между планировщиком и воркерами. Push никогда не
+// блокируется: когда задач в памяти становится больше лимита, худшая
+// половина по порядку обхода сбрасывается на диск отсортированным
+// сегментом и читается обратно, когда сегмент становится лучше задач в
+// памяти. Выданные задачи помнятся до Done, чтобы попасть в снимок состояния
+type Frontier struct {
+	mu          sync.Mutex
+	less        Less
+	queue       *taskHeap             // Задачи в памяти
+	leased      []domain.DownloadTask // Выданные и еще не обработанные задачи
+	segments    []segment             // Сброшенные на диск задачи
+	spilled     int                   // Количество задач в сегментах
+	memoryLimit int
+	baseDir     string // Каталог для временных файлов (пусто - системный)
+	spillDir    string // Создается при первом сбросе
+	sequence    int
+
+	notify chan struct{} // Сигнал о новой задаче
+	done   chan struct{} // Закрывается в Close
+	closed bool
+}
+
+// segment файл со сброшенными на диск задачами, отсортированными по порядку обхода
+type segment struct {
+	path  string
+	count int
+	first domain.DownloadTask // Лучшая задача сегмента
+}
+
+// New создает очередь со стратегией обхода cfg.CrawlOrder, держащую в
+// памяти не больше cfg.FrontierMemory задач
+func New(cfg *config.Config) *Frontier {
+	memoryLimit := cfg.FrontierMemory
+	if memoryLimit < 2 {
+		memoryLimit = 2
+	}
+
+	less := OrderFor(cfg.CrawlOrder)
+	return &Frontier{
+		less:        less,
+		queue:       &taskHeap{less: less},
+		memoryLimit: memoryLimit,
+		baseDir:     cfg.FrontierDir,
+		notify:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+// Push добавляет задачу в очередь
+func (f *Frontier) Push(task domain.DownloadTask) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	heap.Push(f.queue, task)
+	if f.queue.Len() > f.memoryLimit {
+		f.spill()
+	}
+	f.signal()
+}
+
+// Pop извлекает лучшую по порядку обхода задачу, ожидая ее появления.
+// Возвращает false, если очередь закрыта или контекст отменен
+func (f *Frontier) Pop(ctx context.Context) (domain.DownloadTask, bool) {
+	for {
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return domain.DownloadTask{}, false
+		}
+		if task, ok := f.take(); ok {
+			f.leased = append(f.leased, task)
+			if f.lenLocked() > 0 {
+				f.signal()
+			}
+			f.mu.Unlock()
+			return task, true
+		}
+		f.mu.Unlock()
+
+		select {
+		case <-f.notify:
+		case <-f.done:
+		case <-ctx.Done():
+			return domain.DownloadTask{}, false
+		}
+	}
+}
+
+// Done отмечает выданную задачу обработанной
+func (f *Frontier) Done(task domain.DownloadTask) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, leased := range f.leased {
+		if leased.URL == task.URL {
+			f.leased = append(f.leased[:i], f.leased[i+1:]...)
+			return
+		}
+	}
+}
+
+// Snapshot возвращает задачи в работе и все задачи очереди в порядке
+// обхода, включая сброшенные на диск
+func (f *Frontier) Snapshot() []domain.DownloadTask {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queued := append([]domain.DownloadTask(nil), f.queue.tasks...)
+	for _, next := range f.segments {
+		spilled, err := readSegment(next.path)
+		if err != nil {
+			log.Printf("Frontier: cannot read spilled tasks from %s: %v", next.path, err)
+			continue
+		}
+		queued = append(queued, spilled...)
+	}
+	sort.SliceStable(queued, func(i, j int) bool {
+		return f.less(queued[i], queued[j])
+	})
+
+	return append(append([]domain.DownloadTask(nil), f.leased...), queued...)
+}
+
+// Len возвращает количество задач в очереди, включая сброшенные на диск
+func (f *Frontier) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lenLocked()
+}
+
+// Close закрывает очередь, будит ожидающих и удаляет временные файлы
+func (f *Frontier) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.closed = true
+	close(f.done)
+
+	if f.spillDir != "" {
+		os.RemoveAll(f.spillDir)
+	}
+	f.queue.tasks, f.segments, f.spilled, f.leased = nil, nil, 0, nil
+}
+
+func (f *Frontier) lenLocked() int {
+	return f.queue.Len() + f.spilled
+}
+
+// take выдает лучшую задачу, подгружая сегмент с диска, если его лучшая
+// задача должна идти раньше задач в памяти
+func (f *Frontier) take() (domain.DownloadTask, bool) {
+	if best := f.bestSegment(); best >= 0 {
+		if f.queue.Len() == 0 || f.less(f.segments[best].first, f.queue.tasks[0]) {
+			f.load(best)
+		}
+	}
+	if f.queue.Len() == 0 {
+		return domain.DownloadTask{}, false
+	}
+	return heap.Pop(f.queue).(domain.DownloadTask), true
+}
+
+// bestSegment возвращает индекс сегмента с лучшей первой задачей или -1
+func (f *Frontier) bestSegment() int {
+	best := -1
+	for i, next := range f.segments {
+		if best < 0 || f.less(next.first, f.segments[best].first) {
+			best = i
+		}
+	}
+	return best
+}
+
+// spill сбрасывает худшую половину задач в памяти на диск новым сегментом.
+// При ошибке задачи остаются в памяти
+func (f *Frontier) spill() {
+	if f.spillDir == "" {
+		if f.baseDir != "" {
+			if err := os.MkdirAll(f.baseDir, 0755); err != nil {
+				log.Printf("Frontier: cannot spill to disk, keeping tasks in memory: %v", err)
+				return
+			}
+		}
+		dir, err := os.MkdirTemp(f.baseDir, "wget-go-frontier-")
+		if err != nil {
+			log.Printf("Frontier: cannot spill to disk, keeping tasks in memory: %v", err)
+			return
+		}
+		f.spillDir = dir
+		log.Printf("Frontier: more than %d tasks pending, spilling to %s", f.memoryLimit, dir)
+	}
+
+	// Отсортированный срез одновременно является кучей
+	tasks := f.queue.tasks
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return f.less(tasks[i], tasks[j])
+	})
+	keep := len(tasks) / 2
+	worst := tasks[keep:]
+
+	f.sequence++
+	path := filepath.Join(f.spillDir, fmt.Sprintf("segment-%06d.gob", f.sequence))
+	if err := writeSegment(path, worst); err != nil {
+		log.Printf("Frontier: cannot spill to disk, keeping tasks in memory: %v", err)
+		return
+	}
+
+	f.segments = append(f.segments, segment{path: path, count: len(worst), first: worst[0]})
+	f.spilled += len(worst)
+	f.queue.tasks = append([]domain.DownloadTask(nil), tasks[:keep]...)
+}
+
+// load читает сегмент с индексом i в память
+func (f *Frontier) load(i int) {
+	next := f.segments[i]
+	f.segments = append(f.segments[:i], f.segments[i+1:]...)
+	f.spilled -= next.count
+
+	tasks, err := readSegment(next.path)
+	os.Remove(next.path)
+	if err != nil {
+		// Файл создан этим же процессом, ошибка чтения означает потерю задач
+		log.Printf("Frontier: lost %d spilled tasks from %s: %v", next.count, next.path, err)
+		return
+	}
+	for _, task := range tasks {
+		heap.Push(f.queue, task)
+	}
+}
+
+func writeSegment(path string, tasks []domain.DownloadTask) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(file).Encode(tasks); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+func readSegment(path string) ([]domain.DownloadTask, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var tasks []domain.DownloadTask
+	if err := gob.NewDecoder(file).Decode(&tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// signal будит ожидающего в Pop, не блокируясь
+func (f *Frontier) signal() {
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// taskHeap куча задач для container/heap
+type taskHeap struct {
+	tasks []domain.DownloadTask
+	less  Less
+}
+
+func (h *taskHeap) Len() int           { return len(h.tasks) }
+func (h *taskHeap) Less(i, j int) bool { return h.less(h.tasks[i], h.tasks[j]) }
+func (h *taskHeap) Swap(i, j int)      { h.tasks[i], h.tasks[j] = h.tasks[j], h.tasks[i] }
+
+func (h *taskHeap) Push(x interface{}) {
+	h.tasks = append(h.tasks, x.(domain.DownloadTask))
+}
+
+func (h *taskHeap) Pop() interface{} {
+	last := len(h.tasks) - 1
+	task := h.tasks[last]
+	h.tasks[last] = domain.DownloadTask{}
+	h.tasks = h.tasks[:last]
+	return task
+}
@@ -0,0 +1,157 @@
+package frontier
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+func newTestFrontier(t *testing.T, order string, memory int) (*Frontier, string) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.CrawlOrder = order
+	cfg.FrontierMemory = memory
+	cfg.FrontierDir = t.TempDir()
+	return New(cfg), cfg.FrontierDir
+}
+
+// shuffledTasks возвращает задачи нескольких уровней в случайном порядке
+func shuffledTasks(count int, seed int64) []domain.DownloadTask {
+	random := rand.New(rand.NewSource(seed))
+	tasks := make([]domain.DownloadTask, count)
+	for i := range tasks {
+		tasks[i] = domain.DownloadTask{
+			URL:   fmt.Sprintf("https://example.com/%d", i),
+			Depth: random.Intn(4),
+			Seq:   int64(i),
+		}
+	}
+	random.Shuffle(len(tasks), func(i, j int) { tasks[i], tasks[j] = tasks[j], tasks[i] })
+	return tasks
+}
+
+func sortedURLs(tasks []domain.DownloadTask, less Less) []string {
+	sorted := append([]domain.DownloadTask(nil), tasks...)
+	sort.Slice(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return urls(sorted)
+}
+
+func urls(tasks []domain.DownloadTask) []string {
+	result := make([]string, len(tasks))
+	for i, task := range tasks {
+		result[i] = task.URL
+	}
+	return result
+}
+
+func spillFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "wget-go-frontier-*", "segment-*.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSpillAndReloadKeepOrder(t *testing.T) {
+	for _, order := range []string{OrderBFS, OrderDFS} {
+		t.Run(order, func(t *testing.T) {
+			f, dir := newTestFrontier(t, order, 4)
+			defer f.Close()
+
+			tasks := shuffledTasks(50, 7)
+			for _, task := range tasks {
+				f.Push(task)
+			}
+			want := sortedURLs(tasks, OrderFor(order))
+
+			if f.Len() != len(tasks) {
+				t.Fatalf("Len = %d, want %d", f.Len(), len(tasks))
+			}
+			if len(spillFiles(t, dir)) == 0 {
+				t.Fatal("nothing was spilled to disk with a memory limit of 4")
+			}
+			if got := urls(f.Snapshot()); !reflect.DeepEqual(got, want) {
+				t.Fatalf("Snapshot order:\n got %v\nwant %v", got, want)
+			}
+
+			var popped []string
+			for f.Len() > 0 {
+				task, ok := f.Pop(context.Background())
+				if !ok {
+					t.Fatal("Pop returned nothing with tasks queued")
+				}
+				popped = append(popped, task.URL)
+				f.Done(task)
+			}
+			if !reflect.DeepEqual(popped, want) {
+				t.Fatalf("Pop order:\n got %v\nwant %v", popped, want)
+			}
+			if files := spillFiles(t, dir); len(files) != 0 {
+				t.Fatalf("segments left after reload: %v", files)
+			}
+		})
+	}
+}
+
+func TestPushBetweenPopsAfterSpill(t *testing.T) {
+	f, _ := newTestFrontier(t, OrderBFS, 2)
+	defer f.Close()
+
+	for i := 0; i < 6; i++ {
+		f.Push(domain.DownloadTask{URL: fmt.Sprintf("https://example.com/deep%d", i), Depth: 2, Seq: int64(i)})
+	}
+	first, _ := f.Pop(context.Background())
+
+	// Задача меньшей глубины обгоняет сброшенные на диск
+	f.Push(domain.DownloadTask{URL: "https://example.com/shallow", Depth: 1, Seq: 10})
+	next, _ := f.Pop(context.Background())
+	if first.URL != "https://example.com/deep0" || next.URL != "https://example.com/shallow" {
+		t.Fatalf("popped %s then %s, want deep0 then shallow", first.URL, next.URL)
+	}
+
+	// Выданные и не завершенные задачи остаются в снимке
+	snapshot := urls(f.Snapshot())
+	want := []string{
+		"https://example.com/deep0",
+		"https://example.com/shallow",
+		"https://example.com/deep1",
+		"https://example.com/deep2",
+		"https://example.com/deep3",
+		"https://example.com/deep4",
+		"https://example.com/deep5",
+	}
+	if !reflect.DeepEqual(snapshot, want) {
+		t.Fatalf("Snapshot = %v, want %v", snapshot, want)
+	}
+}
+
+func TestCloseRemovesSpillDir(t *testing.T) {
+	f, dir := newTestFrontier(t, OrderBFS, 2)
+	for _, task := range shuffledTasks(10, 1) {
+		f.Push(task)
+	}
+	if len(spillFiles(t, dir)) == 0 {
+		t.Fatal("nothing was spilled to disk")
+	}
+
+	f.Close()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("spill directory left after Close: %v", entries)
+	}
+	if _, ok := f.Pop(context.Background()); ok {
+		t.Fatal("Pop returned a task after Close")
+	}
+}
@@ -0,0 +1,65 @@
+package frontier
+
+import "wget-go/internal/domain"
+
+// Стратегии обхода
+const (
+	// OrderBFS по уровням глубины, внутри уровня в порядке постановки
+	OrderBFS = "bfs"
+	// OrderDFS сначала самые глубокие задачи
+	OrderDFS = "dfs"
+	// OrderRequisitesFirst сначала ресурсы страниц, затем переходы в порядке BFS
+	OrderRequisitesFirst = "requisites-first"
+	// OrderBestFirst по убыванию оценки задачи
+	OrderBestFirst = "best-first"
+)
+
+// Less задает порядок обхода: true, если задача a выдается раньше b.
+// Порядок должен быть полным, иначе выдача зависит от порядка добавления
+type Less func(a, b domain.DownloadTask) bool
+
+// OrderFor возвращает порядок обхода для стратегии, по умолчанию BFS
+func OrderFor(strategy string) Less {
+	switch strategy {
+	case OrderDFS:
+		return dfs
+	case OrderRequisitesFirst:
+		return requisitesFirst
+	case OrderBestFirst:
+		return bestFirst
+	default:
+		return bfs
+	}
+}
+
+func bfs(a, b domain.DownloadTask) bool {
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	return a.Seq < b.Seq
+}
+
+// dfs выдает задачи с большей глубиной раньше: ссылки только что
+// скачанной страницы обходятся до ее соседей по уровню
+func dfs(a, b domain.DownloadTask) bool {
+	if a.Depth != b.Depth {
+		return a.Depth > b.Depth
+	}
+	return a.Seq < b.Seq
+}
+
+func requisitesFirst(a, b domain.DownloadTask) bool {
+	aRequisite := a.Kind == domain.LinkRequisite
+	bRequisite := b.Kind == domain.LinkRequisite
+	if aRequisite != bRequisite {
+		return aRequisite
+	}
+	return bfs(a, b)
+}
+
+func bestFirst(a, b domain.DownloadTask) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return bfs(a, b)
+}
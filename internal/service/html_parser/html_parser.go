@@ -2,6 +2,7 @@ package html_parser
 
 import (
 	"strings"
+	"wget-go/internal/domain"
 
 	"golang.org/x/net/html"
 )
@@ -14,37 +15,60 @@ func New() *Parser {
 	return &Parser{}
 }
 
+// requisiteRels значения rel у <link>, загружающих ресурсы страницы
+var requisiteRels = map[string]bool{
+	"stylesheet":       true,
+	"icon":             true,
+	"shortcut":         true,
+	"apple-touch-icon": true,
+	"preload":          true,
+	"modulepreload":    true,
+	"manifest":         true,
+}
+
 // Parse парсит HTML и возвращает ссылки
-func (p *Parser) Parse(htmlContent []byte, baseURL string) ([]string, error) {
+func (p *Parser) Parse(htmlContent []byte, baseURL string) ([]domain.Link, error) {
 	doc, err := html.Parse(strings.NewReader(string(htmlContent)))
 	if err != nil {
 		return nil, err
 	}
 
-	var links []string
+	var links []domain.Link
 	var extract func(*html.Node)
 
 	extract = func(n *html.Node) {
 		if n.Type == html.ElementNode {
 			switch n.Data {
-			case "a", "link":
+			case "a", "area":
+				if href := getAttribute(n, "href"); href != "" {
+					links = append(links, domain.Link{
+						URL:      href,
+						Kind:     domain.LinkNavigation,
+						NoFollow: hasToken(getAttribute(n, "rel"), "nofollow"),
+					})
+				}
+			case "link":
 				if href := getAttribute(n, "href"); href != "" {
-					links = append(links, href)
+					links = append(links, domain.Link{
+						URL:      href,
+						Kind:     linkKind(getAttribute(n, "rel")),
+						NoFollow: hasToken(getAttribute(n, "rel"), "nofollow"),
+					})
 				}
 			case "img", "script", "iframe", "embed":
 				if src := getAttribute(n, "src"); src != "" {
-					links = append(links, src)
+					links = append(links, domain.Link{URL: src, Kind: domain.LinkRequisite})
 				}
 			case "meta":
 				if getAttribute(n, "property") == "og:image" ||
 					getAttribute(n, "name") == "twitter:image" {
 					if content := getAttribute(n, "content"); content != "" {
-						links = append(links, content)
+						links = append(links, domain.Link{URL: content, Kind: domain.LinkRequisite})
 					}
 				}
 			case "object":
 				if data := getAttribute(n, "data"); data != "" {
-					links = append(links, data)
+					links = append(links, domain.Link{URL: data, Kind: domain.LinkRequisite})
 				}
 			}
 		}
@@ -58,23 +82,49 @@ func (p *Parser) Parse(htmlContent []byte, baseURL string) ([]string, error) {
 	return p.filterLinks(links), nil
 }
 
+// linkKind определяет вид ссылки <link> по атрибуту rel
+func linkKind(rel string) domain.LinkKind {
+	for _, token := range strings.Fields(strings.ToLower(rel)) {
+		if requisiteRels[token] {
+			return domain.LinkRequisite
+		}
+	}
+	return domain.LinkNavigation
+}
+
+// hasToken проверяет, содержит ли список через пробел значение token
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
+
 // filterLinks фильтрует ссылки
-func (p *Parser) filterLinks(links []string) []string {
-	var filtered []string
-	seen := make(map[string]bool)
+func (p *Parser) filterLinks(links []domain.Link) []domain.Link {
+	var filtered []domain.Link
+	seen := make(map[string]int)
 
 	for _, link := range links {
 		// Пропускаем пустые ссылки, якоря и javascript
-		if link == "" || strings.HasPrefix(link, "#") ||
-			strings.HasPrefix(link, "javascript:") || strings.HasPrefix(link, "mailto:") {
+		if link.URL == "" || strings.HasPrefix(link.URL, "#") ||
+			strings.HasPrefix(link.URL, "javascript:") || strings.HasPrefix(link.URL, "mailto:") {
 			continue
 		}
 
-		// Убираем дубликаты
-		if !seen[link] {
-			seen[link] = true
-			filtered = append(filtered, link)
+		// Убираем дубликаты. Ссылка, хотя бы раз встреченная без nofollow,
+		// остается доступной для перехода
+		if i, exists := seen[link.URL]; exists {
+			filtered[i].NoFollow = filtered[i].NoFollow && link.NoFollow
+			if link.Kind == domain.LinkRequisite {
+				filtered[i].Kind = domain.LinkRequisite
+			}
+			continue
 		}
+		seen[link.URL] = len(filtered)
+		filtered = append(filtered, link)
 	}
 
 	return filtered
@@ -0,0 +1,127 @@
+package metalink
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"wget-go/internal/domain"
+)
+
+// namespace пространство имен Metalink v4 (RFC 5854)
+const namespace = "urn:ietf:params:xml:ns:metalink"
+
+// document корневой элемент .meta4 файла
+type document struct {
+	XMLName xml.Name `xml:"metalink"`
+	Files   []struct {
+		Name   string `xml:"name,attr"`
+		Size   int64  `xml:"size"`
+		Hashes []struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"hash"`
+		URLs []struct {
+			Priority int    `xml:"priority,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"url"`
+	} `xml:"file"`
+}
+
+// File файл из Metalink с зеркалами в порядке приоритета
+type File struct {
+	Name      string
+	Size      int64
+	URLs      []string
+	Checksums []domain.Checksum
+}
+
+// Task возвращает задачу скачивания файла: первое зеркало основное, остальные запасные
+func (f File) Task(depth int) domain.DownloadTask {
+	return domain.DownloadTask{
+		URL:       f.URLs[0],
+		Depth:     depth,
+		Type:      domain.ResourceOther,
+		Mirrors:   f.URLs[1:],
+		Checksums: f.Checksums,
+		FileName:  filepath.FromSlash(f.Name),
+		Size:      f.Size,
+	}
+}
+
+// Load читает Metalink v4 документ
+func Load(filePath string) ([]File, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read metalink: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse разбирает Metalink v4 документ
+func Parse(data []byte) ([]File, error) {
+	var doc document
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse metalink: %w", err)
+	}
+	if doc.XMLName.Space != namespace {
+		return nil, fmt.Errorf("parse metalink: unexpected namespace %q, only Metalink v4 is supported", doc.XMLName.Space)
+	}
+
+	files := make([]File, 0, len(doc.Files))
+	for _, f := range doc.Files {
+		name, err := safeName(f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Меньшее значение priority означает более предпочтительное зеркало,
+		// зеркала без приоритета идут последними
+		urls := f.URLs
+		sort.SliceStable(urls, func(i, j int) bool {
+			pi, pj := urls[i].Priority, urls[j].Priority
+			if pi == 0 || pj == 0 {
+				return pi != 0 && pj == 0
+			}
+			return pi < pj
+		})
+
+		file := File{Name: name, Size: f.Size}
+		for _, u := range urls {
+			if value := strings.TrimSpace(u.Value); value != "" {
+				file.URLs = append(file.URLs, value)
+			}
+		}
+		if len(file.URLs) == 0 {
+			return nil, fmt.Errorf("metalink file %q has no URLs", name)
+		}
+
+		for _, h := range f.Hashes {
+			file.Checksums = append(file.Checksums, domain.Checksum{
+				Algorithm: strings.ToLower(strings.TrimSpace(h.Type)),
+				Value:     strings.ToLower(strings.TrimSpace(h.Value)),
+				Source:    "Metalink",
+			})
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("metalink contains no files")
+	}
+	return files, nil
+}
+
+// safeName проверяет имя файла: относительный путь без выхода за каталог вывода
+func safeName(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimSpace(name))
+	if name == "" || cleaned == "." || path.IsAbs(cleaned) ||
+		cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.Contains(cleaned, "\\") {
+		return "", fmt.Errorf("metalink file has unsafe name %q", name)
+	}
+	return cleaned, nil
+}
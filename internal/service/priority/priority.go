@@ -0,0 +1,52 @@
+package priority
+
+import (
+	"regexp"
+	"sort"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+// rule вес для URL, подходящих под шаблон
+type rule struct {
+	pattern *regexp.Regexp
+	weight  float64
+}
+
+// Scorer оценивает задачи для обхода best-first. Оценка складывается из
+// весов подходящих шаблонов URL, приоритета страницы в sitemap и бонуса
+// за позицию ссылки: первая ссылка страницы дает 1, вторая 1/2 и так далее
+type Scorer struct {
+	rules []rule
+}
+
+// New создает оценщик с весами шаблонов из cfg.URLWeights. Шаблоны
+// проверены при валидации конфигурации
+func New(cfg *config.Config) *Scorer {
+	patterns := make([]string, 0, len(cfg.URLWeights))
+	for pattern := range cfg.URLWeights {
+		patterns = append(patterns, pattern)
+	}
+	// Фиксированный порядок сложения дает одинаковую оценку от запуска к запуску
+	sort.Strings(patterns)
+
+	scorer := &Scorer{}
+	for _, pattern := range patterns {
+		scorer.rules = append(scorer.rules, rule{
+			pattern: regexp.MustCompile(pattern),
+			weight:  cfg.URLWeights[pattern],
+		})
+	}
+	return scorer
+}
+
+// Score возвращает оценку задачи: чем больше, тем раньше она скачивается
+func (s *Scorer) Score(task domain.DownloadTask) float64 {
+	score := task.Priority + 1/float64(task.Position+1)
+	for _, r := range s.rules {
+		if r.pattern.MatchString(task.URL) {
+			score += r.weight
+		}
+	}
+	return score
+}
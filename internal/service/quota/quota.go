@@ -0,0 +1,80 @@
+package quota
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+// Tracker учитывает объем скачанных данных и лимиты размера ответов
+type Tracker struct {
+	limit       int64
+	maxFileSize int64
+	typeLimits  map[domain.ResourceType]int64
+
+	used       atomic.Int64
+	exceeded   atomic.Bool
+	reasonOnce sync.Once
+	reason     string
+}
+
+// New создает трекер квоты по конфигурации
+func New(cfg *config.Config) *Tracker {
+	typeLimits := make(map[domain.ResourceType]int64, len(cfg.TypeLimits))
+	for name, size := range cfg.TypeLimits {
+		if rt, ok := domain.ParseResourceType(name); ok && size > 0 {
+			typeLimits[rt] = int64(size)
+		}
+	}
+
+	return &Tracker{
+		limit:       int64(cfg.Quota),
+		maxFileSize: int64(cfg.MaxFileSize),
+		typeLimits:  typeLimits,
+	}
+}
+
+// Add учитывает скачанные байты и отмечает превышение квоты
+func (t *Tracker) Add(n int64) {
+	used := t.used.Add(n)
+	if t.limit > 0 && used >= t.limit {
+		t.reasonOnce.Do(func() {
+			t.reason = fmt.Sprintf("quota of %d bytes exceeded (downloaded %d bytes)", t.limit, used)
+			t.exceeded.Store(true)
+		})
+	}
+}
+
+// Exceeded сообщает, исчерпана ли квота
+func (t *Tracker) Exceeded() bool {
+	return t.exceeded.Load()
+}
+
+// Reason возвращает причину остановки или пустую строку
+func (t *Tracker) Reason() string {
+	if !t.Exceeded() {
+		return ""
+	}
+	return t.reason
+}
+
+// Used возвращает количество скачанных байт
+func (t *Tracker) Used() int64 {
+	return t.used.Load()
+}
+
+// LimitFor возвращает максимальный размер ресурса указанного типа (0 - без ограничения)
+func (t *Tracker) LimitFor(rt domain.ResourceType) int64 {
+	limit := t.maxFileSize
+	if typeLimit, exists := t.typeLimits[rt]; exists && (limit == 0 || typeLimit < limit) {
+		limit = typeLimit
+	}
+	return limit
+}
+
+// Limit возвращает максимальный размер ответа с указанным Content-Type
+func (t *Tracker) Limit(contentType string) int64 {
+	return t.LimitFor(domain.ResourceTypeFromContentType(contentType))
+}
@@ -0,0 +1,157 @@
+package robotsmeta
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parameterized директивы X-Robots-Tag со значением после двоеточия.
+// Их имя нельзя принимать за имя робота
+var parameterized = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// Directives указания сайта о том, как обходить и хранить страницу
+type Directives struct {
+	NoFollow  bool     // Не переходить по ссылкам страницы
+	NoIndex   bool     // Не индексировать страницу
+	NoArchive bool     // Не хранить копию страницы
+	Sources   []string // Откуда взяты запреты: X-Robots-Tag, <meta name="robots">
+}
+
+// DontSave сообщает, что страницу нельзя сохранять: noindex или noarchive
+func (d Directives) DontSave() bool {
+	return d.NoIndex || d.NoArchive
+}
+
+// Reason описывает запреты для журнала пропусков, например "noarchive (X-Robots-Tag)"
+func (d Directives) Reason() string {
+	var names []string
+	if d.NoIndex {
+		names = append(names, "noindex")
+	}
+	if d.NoArchive {
+		names = append(names, "noarchive")
+	}
+	if d.NoFollow {
+		names = append(names, "nofollow")
+	}
+	return strings.Join(names, ", ") + " (" + strings.Join(d.Sources, ", ") + ")"
+}
+
+// Merge объединяет директивы из двух источников: запрет любого из них действует
+func (d Directives) Merge(other Directives) Directives {
+	return Directives{
+		NoFollow:  d.NoFollow || other.NoFollow,
+		NoIndex:   d.NoIndex || other.NoIndex,
+		NoArchive: d.NoArchive || other.NoArchive,
+		Sources:   append(append([]string(nil), d.Sources...), other.Sources...),
+	}
+}
+
+// FromHeader читает заголовки X-Robots-Tag. Значения вида "bot: noindex"
+// учитываются, только если bot совпадает с токеном продукта userAgent
+func FromHeader(header http.Header, userAgent string) Directives {
+	var directives Directives
+	token := productToken(userAgent)
+
+	for _, value := range header.Values("X-Robots-Tag") {
+		scope := ""
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if name, rest, found := strings.Cut(item, ":"); found && !parameterized[strings.ToLower(strings.TrimSpace(name))] {
+				// Имя робота относится к этой и следующим директивам значения
+				scope = strings.TrimSpace(name)
+				item = strings.TrimSpace(rest)
+			}
+			if scope != "" && !strings.EqualFold(scope, token) {
+				continue
+			}
+			directives.apply(item)
+		}
+	}
+
+	directives.addSource("X-Robots-Tag")
+	return directives
+}
+
+// FromHTML читает <meta name="robots"> и <meta name="<робот>"> страницы,
+// где имя робота сравнивается с токеном продукта userAgent
+func FromHTML(content []byte, userAgent string) Directives {
+	var directives Directives
+	token := productToken(userAgent)
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return directives
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag := tokenizer.Token()
+			if tag.Data != "meta" {
+				continue
+			}
+
+			var name, value string
+			for _, attr := range tag.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "name":
+					name = strings.TrimSpace(attr.Val)
+				case "content":
+					value = attr.Val
+				}
+			}
+			if !strings.EqualFold(name, "robots") && (token == "" || !strings.EqualFold(name, token)) {
+				continue
+			}
+
+			var found Directives
+			for _, item := range strings.Split(value, ",") {
+				found.apply(strings.TrimSpace(item))
+			}
+			found.addSource(`<meta name="` + strings.ToLower(name) + `">`)
+			directives = directives.Merge(found)
+		}
+	}
+}
+
+// apply применяет одну директиву
+func (d *Directives) apply(directive string) {
+	switch strings.ToLower(directive) {
+	case "nofollow":
+		d.NoFollow = true
+	case "noindex":
+		d.NoIndex = true
+	case "noarchive":
+		d.NoArchive = true
+	case "none":
+		d.NoIndex = true
+		d.NoFollow = true
+	}
+}
+
+// addSource запоминает источник, если он что-то запретил
+func (d *Directives) addSource(source string) {
+	if d.NoFollow || d.NoIndex || d.NoArchive {
+		d.Sources = append(d.Sources, source)
+	}
+}
+
+// productToken возвращает имя робота из User-Agent: "Wget-Go/1.0" -> "Wget-Go"
+func productToken(userAgent string) string {
+	end := 0
+	for end < len(userAgent) {
+		c := userAgent[end]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_') {
+			break
+		}
+		end++
+	}
+	return userAgent[:end]
+}
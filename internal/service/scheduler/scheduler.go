@@ -2,8 +2,11 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/url"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,16 +23,38 @@ type DownloadScheduler struct {
 	config       *config.Config
 	downloader   service.Downloader
 	pathResolver storage.PathResolver
+	rates        service.RateReporter
+	quota        service.Quota
+	frontier     service.Frontier
+	checkpoint   storage.Checkpoint
+	scorer       service.Scorer
+	scope        service.Scope
+	filter       service.URLFilter
+	skipLog      storage.SkipLog
 	visited      *concurrency.ConcurrentSet
+	excluded     *concurrency.ConcurrentSet // Отброшенные границами и фильтрами URL, о которых уже сообщено
 	workerPool   *concurrency.WorkerPool
+	slots        chan struct{} // Свободные воркеры: задача берется из очереди только под свободный
 	baseURL      *url.URL
+	seeds        []domain.DownloadTask
+
+	outcomes []domain.URLOutcome   // Итоги обработанных URL для снимка состояния
+	deferred []domain.DownloadTask // Задачи, не начатые из-за квоты
+
+	sequence     int64                   // Номер следующей задачи
+	strictLevels bool                    // Строгий BFS: следующий уровень после завершения текущего
+	outstanding  map[int]int             // Незавершенные задачи по глубине
+	held         []domain.DownloadResult // Результаты, ждущие завершения своего уровня
 
 	totalTasks     int32
 	completedTasks int32
 	failedTasks    int32
 	pendingTasks   int32
+	skippedTasks   int32
+	verifiedFiles  int32
 
-	stopChan chan struct{}
+	quotaOnce sync.Once
+	stopChan  chan struct{}
 }
 
 // New создает новый планировщик
@@ -37,6 +62,14 @@ func New(
 	config *config.Config,
 	downloader service.Downloader,
 	pathResolver storage.PathResolver,
+	rates service.RateReporter,
+	quota service.Quota,
+	frontier service.Frontier,
+	checkpoint storage.Checkpoint,
+	scorer service.Scorer,
+	scope service.Scope,
+	filter service.URLFilter,
+	skipLog storage.SkipLog,
 ) *DownloadScheduler {
 	baseURL, _ := url.Parse(config.URL)
 
@@ -44,27 +77,131 @@ func New(
 		config:       config,
 		downloader:   downloader,
 		pathResolver: pathResolver,
+		rates:        rates,
+		quota:        quota,
+		frontier:     frontier,
+		checkpoint:   checkpoint,
+		scorer:       scorer,
+		scope:        scope,
+		filter:       filter,
+		skipLog:      skipLog,
 		visited:      concurrency.NewConcurrentSet(),
+		excluded:     concurrency.NewConcurrentSet(),
 		baseURL:      baseURL,
+		strictLevels: config.CrawlOrder == "bfs",
+		outstanding:  make(map[int]int),
 		stopChan:     make(chan struct{}),
 	}
 }
 
+// AddSeeds добавляет задачи, которые будут запланированы при старте вместе с -url
+func (s *DownloadScheduler) AddSeeds(tasks ...domain.DownloadTask) {
+	s.seeds = append(s.seeds, tasks...)
+}
+
+// Restore восстанавливает состояние прерванного обхода: посещенные URL,
+// итоги и очередь. Вызывается до Start
+func (s *DownloadScheduler) Restore(state *domain.CrawlState) {
+	for _, normalized := range state.Visited {
+		s.visited.Add(normalized)
+	}
+
+	// Задача, чьи ссылки еще не были разобраны, сохраняется в очереди вместе
+	// с итогом; при продолжении она выполняется заново, а итог отбрасывается
+	pending := make(map[string]bool, len(state.Pending))
+	for _, task := range state.Pending {
+		pending[task.URL] = true
+	}
+
+	var completed, failed int32
+	for _, outcome := range state.Outcomes {
+		if pending[outcome.URL] {
+			continue
+		}
+		s.outcomes = append(s.outcomes, outcome)
+		switch outcome.Status {
+		case domain.OutcomeCompleted:
+			completed++
+		case domain.OutcomeFailed:
+			failed++
+		}
+	}
+	atomic.StoreInt32(&s.completedTasks, completed)
+	atomic.StoreInt32(&s.failedTasks, failed)
+	atomic.StoreInt32(&s.totalTasks, completed+failed)
+
+	// Номера задач сохраняются, чтобы порядок обхода не изменился
+	for _, task := range state.Pending {
+		if task.Seq >= s.sequence {
+			s.sequence = task.Seq + 1
+		}
+		s.enqueue(task)
+	}
+
+	log.Printf("Resuming crawl: %d completed, %d failed, %d pending",
+		completed, failed, len(state.Pending))
+}
+
 // Start запускает процесс скачивания
 func (s *DownloadScheduler) Start(ctx context.Context) error {
 
 	s.workerPool = concurrency.NewWorkerPool(s.config.Workers, s.processTask)
+	s.slots = make(chan struct{}, s.config.Workers)
 	results := s.workerPool.Start(ctx)
 
+	// Начальные задачи ставятся до запуска выдачи, чтобы первой ушла
+	// лучшая из них по порядку обхода
 	s.scheduleInitialTask()
+	go s.dispatch(ctx)
+
+	// Продолженный завершенный обход тоже не оставляет задач
+	if atomic.LoadInt32(&s.pendingTasks) == 0 {
+		log.Printf("Nothing to download")
+		s.frontier.Close()
+		return nil
+	}
 	return s.processResults(ctx, results)
 }
 
+// dispatch передает задачи из очереди воркерам. Ожидание свободного воркера
+// блокирует только эту горутину, поэтому обработка результатов и добавление
+// найденных ссылок не ждут воркеров. Задача выбирается из очереди, только
+// когда воркер свободен, чтобы порядок обхода учитывал все найденные к
+// этому моменту ссылки. Пул закрывается здесь же, после закрытия очереди,
+// чтобы не отправить задачу в закрытый канал
+func (s *DownloadScheduler) dispatch(ctx context.Context) {
+	defer s.workerPool.Close()
+
+	for {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		task, ok := s.frontier.Pop(ctx)
+		if !ok {
+			return
+		}
+		if !s.workerPool.SubmitContext(ctx, task) {
+			return
+		}
+	}
+}
+
 // processResults обрабатывает результаты скачивания
 func (s *DownloadScheduler) processResults(ctx context.Context, results <-chan interface{}) error {
 	progressTicker := time.NewTicker(2 * time.Second)
 	defer progressTicker.Stop()
 
+	// Без -resume канал остается nil и никогда не срабатывает
+	var checkpointTick <-chan time.Time
+	if s.checkpoint != nil && s.config.CheckpointInterval > 0 {
+		checkpointTicker := time.NewTicker(s.config.CheckpointInterval)
+		defer checkpointTicker.Stop()
+		checkpointTick = checkpointTicker.C
+	}
+
 	for {
 		select {
 		case result, ok := <-results:
@@ -77,7 +214,8 @@ func (s *DownloadScheduler) processResults(ctx context.Context, results <-chan i
 
 			// Проверяем завершение после обработки каждого результата
 			if s.shouldStop() {
-				s.workerPool.Close()
+				s.saveCheckpoint()
+				s.frontier.Close()
 				s.printFinalStats()
 				return nil
 			}
@@ -85,14 +223,19 @@ func (s *DownloadScheduler) processResults(ctx context.Context, results <-chan i
 		case <-progressTicker.C:
 			s.printProgress()
 
+		case <-checkpointTick:
+			s.saveCheckpoint()
+
 		case <-ctx.Done():
 			log.Printf("Download interrupted by user")
-			s.workerPool.Close()
+			s.saveCheckpoint()
+			s.frontier.Close()
 			s.printFinalStats()
 			return ctx.Err()
 
 		case <-s.stopChan:
-			s.workerPool.Close()
+			s.saveCheckpoint()
+			s.frontier.Close()
 			s.printFinalStats()
 			return nil
 		}
@@ -104,6 +247,11 @@ func (s *DownloadScheduler) processTask(task interface{}) interface{} {
 	downloadTask := task.(domain.DownloadTask)
 	ctx := context.TODO()
 
+	// После исчерпания квоты оставшиеся задачи не начинаем
+	if s.quotaExceeded() {
+		return domain.DownloadResult{Task: downloadTask, Error: service.ErrQuotaExceeded}
+	}
+
 	result, err := s.downloader.Download(ctx, downloadTask)
 	if err != nil {
 		result.Error = err
@@ -112,18 +260,131 @@ func (s *DownloadScheduler) processTask(task interface{}) interface{} {
 	return result
 }
 
-// handleResult обрабатывает результаты скачивания
+// handleResult обрабатывает результаты скачивания. Воркер считается
+// свободным только после постановки ссылок результата в очередь, иначе
+// следующая задача выбиралась бы без них и порядок зависел бы от гонки
 func (s *DownloadScheduler) handleResult(result domain.DownloadResult) {
+	defer func() { <-s.slots }()
 	atomic.AddInt32(&s.pendingTasks, -1)
+	s.outstanding[result.Task.Depth]--
+
+	switch {
+	case errors.Is(result.Error, service.ErrQuotaExceeded):
+		// Квота действует на один запуск: при продолжении такие задачи выполнятся
+		atomic.AddInt32(&s.skippedTasks, 1)
+		s.deferred = append(s.deferred, result.Task)
 
-	if result.Error != nil {
+	case result.Error != nil:
 		atomic.AddInt32(&s.failedTasks, 1)
 		log.Printf("Failed to download %s: %v", result.Task.URL, result.Error)
-	} else {
+		s.outcomes = append(s.outcomes, domain.URLOutcome{
+			URL:    result.Task.URL,
+			Status: domain.OutcomeFailed,
+			Error:  result.Error.Error(),
+		})
+
+	default:
 		atomic.AddInt32(&s.completedTasks, 1)
-		log.Printf("Downloaded %s -> %s", result.Task.URL, result.FilePath)
+		s.outcomes = append(s.outcomes, domain.URLOutcome{
+			URL:      result.Task.URL,
+			Status:   domain.OutcomeCompleted,
+			FilePath: result.FilePath,
+		})
+		if result.FilePath != "" {
+			log.Printf("Downloaded %s -> %s", result.Task.URL, result.FilePath)
+		} else {
+			log.Printf("Fetched %s (not saved)", result.Task.URL)
+		}
+
+		if len(result.Checksums) > 0 {
+			atomic.AddInt32(&s.verifiedFiles, 1)
+			for _, checksum := range result.Checksums {
+				log.Printf("  Verified %s %s (%s)", checksum.Algorithm, checksum.Value, checksum.Source)
+			}
+		}
+	}
+
+	s.discover(result)
+}
+
+// followsLinks проверяет, нужно ли ставить в очередь ссылки результата.
+// После исчерпания квоты ссылки нужны только для продолжения обхода
+func (s *DownloadScheduler) followsLinks(result domain.DownloadResult) bool {
+	canResume := s.checkpoint != nil
+	return result.Error == nil && len(result.Links) > 0 &&
+		(s.followsNavigation(result.Task) || s.followsRequisites(result.Task)) &&
+		(!s.quotaExceeded() || canResume)
+}
+
+// followsNavigation проверяет, нужно ли переходить по ссылкам страницы.
+// С -sitemaps-only переходы не выполняются, а со страниц за границами
+// обхода для переходов (хосты -requisite-host) ссылки не берутся
+func (s *DownloadScheduler) followsNavigation(task domain.DownloadTask) bool {
+	if task.Depth >= s.config.MaxDepth || s.config.SitemapsOnly {
+		return false
+	}
+	allowed, _ := s.scope.Allowed(task.URL, domain.LinkNavigation)
+	return allowed
+}
+
+// followsRequisites проверяет, нужно ли скачивать ресурсы страницы. С
+// -page-requisites ресурсы нужны каждой странице независимо от глубины
+func (s *DownloadScheduler) followsRequisites(task domain.DownloadTask) bool {
+	return s.config.PageRequisites || task.Depth < s.config.MaxDepth && !s.config.SitemapsOnly
+}
+
+// discover ставит в очередь ссылки результата. При строгом BFS результат
+// придерживается до завершения всех задач его уровня, а затем результаты
+// уровня разбираются в порядке постановки задач. Так набор, глубина и
+// порядок задач следующего уровня не зависят от того, какой воркер
+// закончил раньше. Придержанная задача остается выданной, чтобы при
+// прерывании попасть в снимок состояния
+func (s *DownloadScheduler) discover(result domain.DownloadResult) {
+	switch {
+	case !s.followsLinks(result):
+		s.frontier.Done(result.Task)
+	case s.strictLevels:
+		result.Content = nil
+		s.held = append(s.held, result)
+	default:
+		s.frontier.Done(result.Task)
+		s.scheduleNewTasks(result)
+	}
+
+	s.releaseLevels()
+}
+
+// releaseLevels разбирает придержанные результаты уровней, все задачи
+// которых завершены
+func (s *DownloadScheduler) releaseLevels() {
+	for len(s.held) > 0 {
+		depth := s.held[0].Task.Depth
+		for _, result := range s.held {
+			if result.Task.Depth < depth {
+				depth = result.Task.Depth
+			}
+		}
+		for level, count := range s.outstanding {
+			if level <= depth && count > 0 {
+				return
+			}
+		}
+
+		var ready, rest []domain.DownloadResult
+		for _, result := range s.held {
+			if result.Task.Depth == depth {
+				ready = append(ready, result)
+			} else {
+				rest = append(rest, result)
+			}
+		}
+		sort.Slice(ready, func(i, j int) bool {
+			return ready[i].Task.Seq < ready[j].Task.Seq
+		})
 
-		if result.Task.Depth < s.config.MaxDepth {
+		s.held = rest
+		for _, result := range ready {
+			s.frontier.Done(result.Task)
 			s.scheduleNewTasks(result)
 		}
 	}
@@ -131,13 +392,21 @@ func (s *DownloadScheduler) handleResult(result domain.DownloadResult) {
 
 // sheduleNewTasks добавляет новые задачи на основе найденных ссылок
 func (s *DownloadScheduler) scheduleNewTasks(result domain.DownloadResult) {
-	for _, link := range result.Links {
-		absoluteURL, err := s.pathResolver.ResolveAbsoluteURL(result.Task.URL, link)
+	navigation := s.followsNavigation(result.Task)
+	requisites := s.followsRequisites(result.Task)
+
+	for position, link := range result.Links {
+		requisite := link.Kind == domain.LinkRequisite
+		if requisite && !requisites || !requisite && !navigation {
+			continue
+		}
+
+		absoluteURL, err := s.pathResolver.ResolveAbsoluteURL(result.Task.URL, link.URL)
 		if err != nil {
 			continue
 		}
 
-		if !s.shouldDownload(absoluteURL) {
+		if !s.shouldDownload(absoluteURL, link.Kind) {
 			continue
 		}
 
@@ -145,38 +414,130 @@ func (s *DownloadScheduler) scheduleNewTasks(result domain.DownloadResult) {
 			URL:       absoluteURL,
 			Depth:     result.Task.Depth + 1,
 			ParentURL: result.Task.URL,
+			Kind:      link.Kind,
+			Position:  position,
 		}
 
+		if s.quotaExceeded() {
+			s.deferred = append(s.deferred, s.prepare(newTask))
+			continue
+		}
 		s.Schedule(newTask)
 	}
 }
 
 // Schedule добавляет новую задачу в планировщик
 func (s *DownloadScheduler) Schedule(task domain.DownloadTask) {
+	s.enqueue(s.prepare(task))
+}
+
+// prepare присваивает задаче номер и оценку для порядка обхода
+func (s *DownloadScheduler) prepare(task domain.DownloadTask) domain.DownloadTask {
+	task.Seq = s.sequence
+	s.sequence++
+	if s.scorer != nil {
+		task.Score = s.scorer.Score(task)
+	}
+	return task
+}
+
+// enqueue учитывает задачу и передает ее в очередь
+func (s *DownloadScheduler) enqueue(task domain.DownloadTask) {
 	atomic.AddInt32(&s.totalTasks, 1)
 	atomic.AddInt32(&s.pendingTasks, 1)
-	s.workerPool.Submit(task)
+	s.outstanding[task.Depth]++
+	s.frontier.Push(task)
 }
 
-// Stats возвращает статистику планировщика
+// scheduleInitialTask планирует -url и начальные задачи из sitemap и
+// metalink. Начальные задачи проходят те же границы обхода и фильтры, что
+// и найденные ссылки
 func (s *DownloadScheduler) scheduleInitialTask() {
-	initialTask := domain.DownloadTask{
-		URL:   s.config.URL,
-		Depth: 0,
-		Type:  domain.ResourceHTML,
+	// С -sitemaps-only стартовый URL нужен только для поиска sitemap
+	// При продолжении обхода стартовый URL уже есть среди посещенных
+	if s.config.URL != "" && !s.config.SitemapsOnly && s.visited.Add(s.normalizeURL(s.config.URL)) {
+		initialTask := domain.DownloadTask{
+			URL:   s.config.URL,
+			Depth: 0,
+			Type:  domain.ResourceHTML,
+		}
+		s.Schedule(initialTask)
+	}
+
+	for _, task := range s.seeds {
+		if s.shouldDownload(task.URL, task.Kind) {
+			s.Schedule(task)
+		}
 	}
-	s.Schedule(initialTask)
-	s.visited.Add(s.normalizeURL(s.config.URL))
 }
 
-// shouldDownload проверяет, нужно ли скачать ссылку
-func (s *DownloadScheduler) shouldDownload(testURL string) bool {
-	if !s.pathResolver.IsSameDomain(s.config.URL, testURL) {
+// saveCheckpoint сохраняет снимок состояния обхода, если задан -resume.
+// Вызывается из горутины обработки результатов, поэтому очередь,
+// посещенные URL и итоги согласованы между собой
+func (s *DownloadScheduler) saveCheckpoint() {
+	if s.checkpoint == nil {
+		return
+	}
+
+	state := &domain.CrawlState{
+		Pending:  append(s.frontier.Snapshot(), s.deferred...),
+		Visited:  s.visited.Items(),
+		Outcomes: s.outcomes,
+	}
+	if err := s.checkpoint.Save(state); err != nil {
+		log.Printf("Failed to save crawl state: %v", err)
+	}
+}
+
+// shouldDownload проверяет, нужно ли скачать ссылку: она входит в
+// границы обхода, проходит фильтры URL и еще не посещалась
+func (s *DownloadScheduler) shouldDownload(testURL string, kind domain.LinkKind) bool {
+	decision := CheckURL(s.scope, s.filter, testURL, kind)
+	if !decision.Allowed {
+		s.reportExcluded(decision.Normalized, decision.Reason)
 		return false
 	}
+	return s.visited.Add(decision.Normalized)
+}
 
-	normalized := s.normalizeURL(testURL)
-	return s.visited.Add(normalized)
+// URLDecision решение о ссылке по границам обхода и фильтрам
+type URLDecision struct {
+	Normalized string // URL, по которому учитываются посещенные
+	Allowed    bool
+	Reason     string // Исключившее URL правило для журнала пропусков
+}
+
+// CheckURL решает, пропускают ли ссылку вида kind границы обхода и
+// фильтры. Планировщик и -test-url используют одну проверку, поэтому
+// объяснение правил совпадает с обходом
+func CheckURL(scope service.Scope, filter service.URLFilter, rawURL string, kind domain.LinkKind) URLDecision {
+	// Встроенные data: ресурсы декодируются независимо от домена
+	if utils.IsDataURL(rawURL) {
+		return URLDecision{Normalized: rawURL, Allowed: true}
+	}
+
+	normalized, err := utils.NormalizeURL(rawURL)
+	if err != nil {
+		normalized = rawURL
+	}
+	if allowed, rule := scope.Allowed(rawURL, kind); !allowed {
+		return URLDecision{Normalized: normalized, Reason: "out of scope: " + rule}
+	}
+	if allowed, rule := filter.Allowed(normalized); !allowed {
+		return URLDecision{Normalized: normalized, Reason: "filtered: " + rule}
+	}
+	return URLDecision{Normalized: normalized, Allowed: true}
+}
+
+// reportExcluded записывает в журнал пропусков URL, отброшенный границами
+// обхода или фильтрами, и правило. О каждом URL сообщается один раз
+func (s *DownloadScheduler) reportExcluded(normalized, reason string) {
+	if !s.excluded.Add(normalized) {
+		return
+	}
+	if s.skipLog != nil {
+		s.skipLog.Record(normalized, reason)
+	}
 }
 
 // normalizeURL нормализует URL
@@ -188,15 +549,36 @@ func (s *DownloadScheduler) normalizeURL(rawURL string) string {
 	return normalized
 }
 
+// quotaExceeded проверяет квоту и один раз сообщает о ее исчерпании
+func (s *DownloadScheduler) quotaExceeded() bool {
+	if s.quota == nil || !s.quota.Exceeded() {
+		return false
+	}
+
+	s.quotaOnce.Do(func() {
+		log.Printf("Stopping: %s, no new tasks will be scheduled", s.quota.Reason())
+	})
+	return true
+}
+
+// stopReason возвращает причину досрочной остановки
+func (s *DownloadScheduler) stopReason() string {
+	if s.quota == nil {
+		return ""
+	}
+	return s.quota.Reason()
+}
+
 // shouldStop проверяет, нужно ли остановить процесс
 func (s *DownloadScheduler) shouldStop() bool {
 	total := atomic.LoadInt32(&s.totalTasks)
 	completed := atomic.LoadInt32(&s.completedTasks)
 	failed := atomic.LoadInt32(&s.failedTasks)
+	skipped := atomic.LoadInt32(&s.skippedTasks)
 	pending := atomic.LoadInt32(&s.pendingTasks)
 
 	// Останавливаемся когда все задачи завершены и нет ожидающих
-	return total > 0 && pending == 0 && total == completed+failed
+	return total > 0 && pending == 0 && total == completed+failed+skipped
 }
 
 // printProgress выводит прогресс
@@ -208,8 +590,8 @@ func (s *DownloadScheduler) printProgress() {
 
 	progress := s.calculateProgress(total, completed)
 
-	log.Printf("Progress: %.1f%% | Completed: %d | Failed: %d | Pending: %d | Total: %d",
-		progress, completed, failed, pending, total)
+	log.Printf("Progress: %.1f%% | Completed: %d | Failed: %d | Pending: %d | Queued: %d | Total: %d",
+		progress, completed, failed, pending, s.frontier.Len(), total)
 }
 
 // printFinalStats вычисляет и выводит прогресс
@@ -231,7 +613,25 @@ func (s *DownloadScheduler) printFinalStats() {
 	log.Printf("  Total URLs processed: %d", s.visited.Size())
 	log.Printf("  Tasks completed: %d", completed)
 	log.Printf("  Tasks failed: %d", failed)
+	if skipped := atomic.LoadInt32(&s.skippedTasks); skipped > 0 {
+		log.Printf("  Tasks skipped: %d", skipped)
+	}
+	if excluded := s.excluded.Size(); excluded > 0 {
+		log.Printf("  Excluded by scope and filters: %d", excluded)
+	}
+	if verified := atomic.LoadInt32(&s.verifiedFiles); verified > 0 {
+		log.Printf("  Files verified: %d", verified)
+	}
 	log.Printf("  Success rate: %.1f%%", s.calculateSuccessRate(total, completed))
+	if reason := s.stopReason(); reason != "" {
+		log.Printf("  Stopped early: %s", reason)
+	}
+
+	if s.config.Adaptive && s.rates != nil {
+		for host, rate := range s.rates.Rates() {
+			log.Printf("  Rate for %s: %d/sec", host, rate)
+		}
+	}
 }
 
 // calculateSuccessRate вычисляет успешность
@@ -249,5 +649,17 @@ func (s *DownloadScheduler) Stats() service.SchedulerStats {
 		CompletedTasks: int(atomic.LoadInt32(&s.completedTasks)),
 		FailedTasks:    int(atomic.LoadInt32(&s.failedTasks)),
 		ActiveWorkers:  s.config.Workers,
+		SkippedTasks:   int(atomic.LoadInt32(&s.skippedTasks)),
+		VerifiedFiles:  int(atomic.LoadInt32(&s.verifiedFiles)),
+		StopReason:     s.stopReason(),
+		HostRates:      s.hostRates(),
+	}
+}
+
+// hostRates возвращает текущие скорости по хостам
+func (s *DownloadScheduler) hostRates() map[string]int {
+	if s.rates == nil {
+		return nil
 	}
+	return s.rates.Rates()
 }
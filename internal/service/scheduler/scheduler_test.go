@@ -0,0 +1,220 @@
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+	"wget-go/internal/service"
+	"wget-go/internal/service/frontier"
+	"wget-go/internal/service/scope"
+	"wget-go/internal/service/urlfilter"
+	"wget-go/internal/storage/path_resolver"
+)
+
+// recordingSkipLog запоминает причины пропуска по URL
+type recordingSkipLog map[string]string
+
+func (l recordingSkipLog) Record(url, reason string) {
+	l[url] = reason
+}
+
+func newTestScheduler(cfg *config.Config, skipLog recordingSkipLog) *DownloadScheduler {
+	return New(cfg, nil, nil, nil, nil, frontier.New(cfg), nil, nil, scope.New(cfg), urlfilter.New(cfg), skipLog)
+}
+
+// TestCrawlAgreesWithCheckURL проверяет, что обход пропускает и отбрасывает
+// ссылки так же, как объясняет -test-url
+func TestCrawlAgreesWithCheckURL(t *testing.T) {
+	cfg := config.Default()
+	cfg.URL = "https://example.com/docs/"
+	cfg.NoParent = true
+	cfg.RequisiteHosts = []string{"cdn.example.net"}
+	cfg.RejectGlob = []string{"/docs/search?*"}
+	cfg.ExcludeDirectories = []string{"/docs/old"}
+
+	for _, tc := range []struct {
+		url  string
+		kind domain.LinkKind
+		want bool
+	}{
+		{url: "https://example.com/docs/guide.html", kind: domain.LinkNavigation, want: true},
+		{url: "http://www.example.com/docs/http.html", kind: domain.LinkNavigation, want: true},
+		{url: "https://example.com/blog/", kind: domain.LinkNavigation, want: false},
+		{url: "https://example.com/static/site.css", kind: domain.LinkRequisite, want: true},
+		{url: "https://cdn.example.net/app.js", kind: domain.LinkRequisite, want: true},
+		{url: "https://cdn.example.net/page.html", kind: domain.LinkNavigation, want: false},
+		{url: "https://example.com/docs/search?q=go", kind: domain.LinkNavigation, want: false},
+		{url: "https://example.com/docs/old/index.html", kind: domain.LinkNavigation, want: false},
+		{url: "https://other.org/", kind: domain.LinkNavigation, want: false},
+	} {
+		skipLog := recordingSkipLog{}
+		s := newTestScheduler(cfg, skipLog)
+
+		decision := CheckURL(s.scope, s.filter, tc.url, tc.kind)
+		crawled := s.shouldDownload(tc.url, tc.kind)
+
+		if decision.Allowed != tc.want || crawled != tc.want {
+			t.Errorf("%s: CheckURL = %v (%s), crawl = %v, want %v", tc.url, decision.Allowed, decision.Reason, crawled, tc.want)
+			continue
+		}
+		if !crawled && skipLog[decision.Normalized] != decision.Reason {
+			t.Errorf("%s: skip log reason %q, -test-url reason %q", tc.url, skipLog[decision.Normalized], decision.Reason)
+		}
+	}
+}
+
+func TestSeedsPassScopeAndFilters(t *testing.T) {
+	cfg := config.Default()
+	cfg.URL = "https://example.com/"
+	cfg.RejectRegex = []string{`\.zip$`}
+
+	s := newTestScheduler(cfg, recordingSkipLog{})
+	s.AddSeeds(
+		domain.DownloadTask{URL: "https://example.com/from-sitemap.html", Type: domain.ResourceHTML},
+		domain.DownloadTask{URL: "https://elsewhere.org/page.html", Type: domain.ResourceHTML},
+		domain.DownloadTask{URL: "https://example.com/archive.zip"},
+	)
+	s.scheduleInitialTask()
+
+	var queued []string
+	for _, task := range s.frontier.Snapshot() {
+		queued = append(queued, task.URL)
+	}
+	want := []string{"https://example.com/", "https://example.com/from-sitemap.html"}
+	if len(queued) != len(want) {
+		t.Fatalf("queued %v, want %v", queued, want)
+	}
+	for _, url := range want {
+		if !s.visited.Contains(s.normalizeURL(url)) {
+			t.Errorf("%s was not scheduled, queued %v", url, queued)
+		}
+	}
+}
+
+// testSite граф ссылок сайта для проверки порядка обхода
+var testSite = map[string][]domain.Link{
+	"/": {
+		{URL: "/a"},
+		{URL: "/b"},
+		{URL: "/style.css", Kind: domain.LinkRequisite},
+		{URL: "/c"},
+	},
+	"/a": {
+		{URL: "/a1"},
+		{URL: "/a2"},
+		{URL: "/shared.js", Kind: domain.LinkRequisite},
+	},
+	"/b": {
+		{URL: "/b1"},
+		{URL: "/shared.js", Kind: domain.LinkRequisite},
+		{URL: "/style.css", Kind: domain.LinkRequisite},
+	},
+	"/c":  {{URL: "/c1"}},
+	"/a1": {{URL: "/deep"}},
+}
+
+// graphDownloader отдает ссылки testSite со случайной задержкой, чтобы
+// воркеры завершали задачи в разном порядке
+type graphDownloader struct {
+	random *rand.Rand
+	mu     sync.Mutex
+}
+
+func (d *graphDownloader) Download(_ context.Context, task domain.DownloadTask) (domain.DownloadResult, error) {
+	d.mu.Lock()
+	delay := time.Duration(d.random.Intn(3000)) * time.Microsecond
+	d.mu.Unlock()
+	time.Sleep(delay)
+
+	parsed, _ := url.Parse(task.URL)
+	return domain.DownloadResult{Task: task, Links: testSite[parsed.Path]}, nil
+}
+
+// recordingFrontier запоминает порядок выдачи задач воркерам
+type recordingFrontier struct {
+	service.Frontier
+	mu     sync.Mutex
+	popped []domain.DownloadTask
+}
+
+func (f *recordingFrontier) Pop(ctx context.Context) (domain.DownloadTask, bool) {
+	task, ok := f.Frontier.Pop(ctx)
+	if ok {
+		f.mu.Lock()
+		f.popped = append(f.popped, task)
+		f.mu.Unlock()
+	}
+	return task, ok
+}
+
+// crawlOrder обходит testSite и возвращает пути в порядке выдачи и их глубины
+func crawlOrder(t *testing.T, order string, workers int, seed int64) ([]string, []int) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.URL = "http://example.com/"
+	cfg.CrawlOrder = order
+	cfg.Workers = workers
+	cfg.MaxDepth = 5
+
+	queue := &recordingFrontier{Frontier: frontier.New(cfg)}
+	s := New(cfg, &graphDownloader{random: rand.New(rand.NewSource(seed))}, path_resolver.New(t.TempDir()),
+		nil, nil, queue, nil, nil, scope.New(cfg), urlfilter.New(cfg), nil)
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	var depths []int
+	for _, task := range queue.popped {
+		parsed, _ := url.Parse(task.URL)
+		paths = append(paths, parsed.Path)
+		depths = append(depths, task.Depth)
+	}
+	return paths, depths
+}
+
+func TestBFSOrderIsReproducible(t *testing.T) {
+	want := []string{"/", "/a", "/b", "/style.css", "/c", "/a1", "/a2", "/shared.js", "/b1", "/c1", "/deep"}
+
+	for seed := int64(1); seed <= 5; seed++ {
+		paths, depths := crawlOrder(t, "bfs", 4, seed)
+		if !reflect.DeepEqual(paths, want) {
+			t.Fatalf("run %d fetched %v, want %v", seed, paths, want)
+		}
+		if !sort.IntsAreSorted(depths) {
+			t.Fatalf("run %d fetched levels out of order: %v", seed, depths)
+		}
+	}
+}
+
+func TestDFSAndRequisitesFirstOrder(t *testing.T) {
+	for _, tc := range []struct {
+		order string
+		want  []string
+	}{
+		{
+			order: "dfs",
+			want:  []string{"/", "/a", "/a1", "/deep", "/a2", "/shared.js", "/b", "/b1", "/style.css", "/c", "/c1"},
+		},
+		{
+			order: "requisites-first",
+			want:  []string{"/", "/style.css", "/a", "/shared.js", "/b", "/c", "/a1", "/a2", "/b1", "/c1", "/deep"},
+		},
+	} {
+		t.Run(tc.order, func(t *testing.T) {
+			for seed := int64(1); seed <= 3; seed++ {
+				if paths, _ := crawlOrder(t, tc.order, 1, seed); !reflect.DeepEqual(paths, tc.want) {
+					t.Fatalf("run %d fetched %v, want %v", seed, paths, tc.want)
+				}
+			}
+		})
+	}
+}
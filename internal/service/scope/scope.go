@@ -0,0 +1,286 @@
+package scope
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+// defaultPorts порты схем по умолчанию для сравнения с -ports
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"ftp":   21,
+}
+
+// Scope решает, входит ли URL в границы обхода. Правила проверяются по
+// порядку, и первое не пропустившее URL называется в причине:
+//
+//  1. схема из -schemes (по умолчанию http, https и схема -url)
+//  2. порт из -ports, если список задан
+//  3. хост не входит в -exclude-domains
+//  4. хост совпадает с -url без учета www., хост входит в -domains,
+//     разрешен -span-hosts, а для ресурсов страниц - -requisite-host
+//  5. с -no-parent страницы хоста -url лежат в каталоге -url или ниже
+//
+// Порт хоста -url учитывается только явный: http и https на портах по
+// умолчанию (80 и 443) - один сайт, а другой порт того же хоста - чужой.
+// С -ports порт решает только список. Для file:// хоста нет, поэтому
+// страницы должны лежать в каталоге -url или ниже, а ресурсы страниц
+// берутся из любого каталога. Без -url (только -metalink) правило хоста
+// не применяется
+type Scope struct {
+	schemes        []string
+	ports          []int
+	startHost      string // Хост -url без www.
+	startPort      string // Явный порт -url (пусто - порт схемы по умолчанию)
+	startSite      string // Хост -url с портом, как он указан, для сообщений
+	startFile      bool   // -url - локальный файл (file://)
+	startDir       string // Каталог -url
+	spanHosts      bool
+	domains        []string
+	excludeDomains []string
+	requisiteHosts []string
+	parentDir      string // Каталог -url для -no-parent (пусто - не проверяется)
+}
+
+// New создает границы обхода по конфигурации. Списки доменов проверены
+// при валидации конфигурации
+func New(cfg *config.Config) *Scope {
+	s := &Scope{
+		schemes:        normalizeList(cfg.Schemes),
+		ports:          cfg.Ports,
+		spanHosts:      cfg.SpanHosts,
+		domains:        normalizeDomains(cfg.Domains),
+		excludeDomains: normalizeDomains(cfg.ExcludeDomains),
+		requisiteHosts: normalizeList(cfg.RequisiteHosts),
+	}
+
+	defaultSchemes := len(s.schemes) == 0
+	if defaultSchemes {
+		s.schemes = []string{"http", "https"}
+	}
+
+	start, err := url.Parse(cfg.URL)
+	if err != nil || cfg.URL == "" {
+		return s
+	}
+	scheme := strings.ToLower(start.Scheme)
+	s.startHost = trimWWW(strings.ToLower(start.Hostname()))
+	s.startPort = explicitPort(start)
+	s.startSite = trimWWW(strings.ToLower(start.Host))
+	s.startFile = scheme == "file"
+	s.startDir = parentDir(start.Path)
+	// Каталог file:// без слеша на конце (file:///site) сам ограничивает обход
+	if info, err := os.Stat(filepath.FromSlash(start.Path)); s.startFile && err == nil && info.IsDir() {
+		s.startDir = strings.TrimSuffix(start.Path, "/") + "/"
+	}
+	// Обход ftp:// или file:// без -schemes остается в схеме -url
+	if defaultSchemes && !contains(s.schemes, scheme) {
+		s.schemes = append(s.schemes, scheme)
+	}
+	if cfg.NoParent {
+		s.parentDir = parentDir(start.Path)
+	}
+	return s
+}
+
+// Allowed проверяет URL ссылки вида kind. Для URL за границами обхода
+// возвращает описание исключившего его правила
+func (s *Scope) Allowed(rawURL string, kind domain.LinkKind) (bool, string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false, "malformed URL"
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if !contains(s.schemes, scheme) {
+		return false, fmt.Sprintf("scheme %s is not allowed (-schemes %s)", scheme, strings.Join(s.schemes, ","))
+	}
+
+	if len(s.ports) > 0 {
+		port := effectivePort(parsed)
+		if !containsPort(s.ports, port) {
+			return false, fmt.Sprintf("port %d is not allowed (-ports %s)", port, joinPorts(s.ports))
+		}
+	}
+
+	if scheme == "file" {
+		return s.allowedFile(parsed, kind)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if excluded := matchDomain(host, s.excludeDomains); excluded != "" {
+		return false, fmt.Sprintf("host %s matches -exclude-domains %s", host, excluded)
+	}
+
+	sameSite := s.isStartSite(parsed, host)
+	switch {
+	case sameSite:
+	case s.startHost == "" && !s.startFile && len(s.domains) == 0:
+	case matchDomain(host, s.domains) != "":
+	case s.spanHosts && len(s.domains) == 0:
+	case kind == domain.LinkRequisite && s.isRequisiteHost(host, strings.ToLower(parsed.Host)):
+	case len(s.domains) > 0:
+		return false, fmt.Sprintf("host %s is not in -domains %s", host, strings.Join(s.domains, ","))
+	default:
+		return false, fmt.Sprintf("host %s is not %s (use -span-hosts or -domains)", parsed.Host, s.startSite)
+	}
+
+	// Ресурсы страниц нужны для отображения и часто лежат выше по дереву
+	if s.parentDir != "" && sameSite && kind != domain.LinkRequisite && !inDir(parsed.Path, s.parentDir) {
+		return false, fmt.Sprintf("path %s is outside %s (-no-parent)", urlPath(parsed), s.parentDir)
+	}
+
+	return true, ""
+}
+
+// isStartSite проверяет, на хосте ли -url ссылка. Без -ports порты схем по
+// умолчанию равноценны, так что переход с http на https остается на сайте
+func (s *Scope) isStartSite(u *url.URL, host string) bool {
+	if s.startHost == "" || trimWWW(host) != s.startHost {
+		return false
+	}
+	return len(s.ports) > 0 || explicitPort(u) == s.startPort
+}
+
+// allowedFile проверяет локальный файл: страницы обходятся только в
+// каталоге -url и ниже, ресурсы страниц - в любом каталоге
+func (s *Scope) allowedFile(u *url.URL, kind domain.LinkKind) (bool, string) {
+	if !s.startFile {
+		return false, fmt.Sprintf("file %s is outside the crawl (-url is not a file:// URL)", urlPath(u))
+	}
+	if kind != domain.LinkRequisite && !inDir(u.Path, s.startDir) {
+		return false, fmt.Sprintf("file %s is outside %s", urlPath(u), s.startDir)
+	}
+	return true, ""
+}
+
+// isRequisiteHost проверяет, входит ли хост в -requisite-host.
+// Шаблон *.example.com разрешает example.com и все его поддомены
+func (s *Scope) isRequisiteHost(host, hostPort string) bool {
+	for _, pattern := range s.requisiteHosts {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern || hostPort == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain возвращает домен из списка, которому принадлежит хост
+// (сам домен или его поддомен), или пустую строку
+func matchDomain(host string, domains []string) string {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return d
+		}
+	}
+	return ""
+}
+
+// parentDir возвращает каталог пути -url со слешем на конце
+func parentDir(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path[:strings.LastIndex(path, "/")+1]
+}
+
+// inDir проверяет, лежит ли путь в каталоге dir (со слешем на конце) или
+// совпадает с ним
+func inDir(path, dir string) bool {
+	if path == "" {
+		path = "/"
+	}
+	return strings.HasPrefix(path, dir) || path+"/" == dir
+}
+
+func urlPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// explicitPort возвращает порт URL или пустую строку, если это порт схемы
+// по умолчанию
+func explicitPort(u *url.URL) string {
+	port := u.Port()
+	if port == strconv.Itoa(defaultPorts[strings.ToLower(u.Scheme)]) {
+		return ""
+	}
+	return port
+}
+
+// effectivePort возвращает порт URL с учетом порта схемы по умолчанию
+func effectivePort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	return defaultPorts[strings.ToLower(u.Scheme)]
+}
+
+func trimWWW(host string) string {
+	return strings.TrimPrefix(host, "www.")
+}
+
+// normalizeDomains приводит домены к нижнему регистру без точек по краям и
+// без префикса *.: поддомены совпадают с доменом и так
+func normalizeDomains(domains []string) []string {
+	var normalized []string
+	for _, d := range normalizeList(domains) {
+		d = strings.Trim(strings.TrimPrefix(d, "*."), ".")
+		if d != "" {
+			normalized = append(normalized, d)
+		}
+	}
+	return normalized
+}
+
+func normalizeList(values []string) []string {
+	var normalized []string
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value != "" {
+			normalized = append(normalized, value)
+		}
+	}
+	return normalized
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,99 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+func newScope(startURL string, configure func(cfg *config.Config)) *Scope {
+	cfg := config.Default()
+	cfg.URL = startURL
+	if configure != nil {
+		configure(cfg)
+	}
+	return New(cfg)
+}
+
+func TestAllowedTreatsDefaultPortsAsOneSite(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		start string
+		url   string
+		ports []int
+		want  bool
+	}{
+		{name: "http to https", start: "http://example.com/", url: "https://example.com/login", want: true},
+		{name: "https to http", start: "https://www.example.com/", url: "http://example.com/", want: true},
+		{name: "explicit default port", start: "http://example.com:80/", url: "https://example.com:443/", want: true},
+		{name: "other port", start: "http://example.com/", url: "http://example.com:8080/", want: false},
+		{name: "same custom port", start: "http://example.com:8080/", url: "http://example.com:8080/a", want: true},
+		{name: "custom port to default", start: "http://example.com:8080/", url: "https://example.com/", want: false},
+		{name: "port rule decides", start: "http://example.com/", url: "http://example.com:8080/", ports: []int{80, 8080}, want: true},
+		{name: "port rule rejects", start: "http://example.com/", url: "https://example.com/", ports: []int{80}, want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newScope(tc.start, func(cfg *config.Config) { cfg.Ports = tc.ports })
+			if got, rule := s.Allowed(tc.url, domain.LinkNavigation); got != tc.want {
+				t.Fatalf("Allowed(%s) = %v (%s), want %v", tc.url, got, rule, tc.want)
+			}
+		})
+	}
+}
+
+func TestAllowedFileURLs(t *testing.T) {
+	s := newScope("file:///srv/site/index.html", nil)
+
+	for _, tc := range []struct {
+		url  string
+		kind domain.LinkKind
+		want bool
+	}{
+		{url: "file:///srv/site/about.html", kind: domain.LinkNavigation, want: true},
+		{url: "file:///srv/site/docs/guide.html", kind: domain.LinkNavigation, want: true},
+		{url: "file:///srv/site", kind: domain.LinkNavigation, want: true},
+		{url: "file:///srv/other/page.html", kind: domain.LinkNavigation, want: false},
+		{url: "file:///etc/passwd", kind: domain.LinkNavigation, want: false},
+		{url: "file:///srv/shared/style.css", kind: domain.LinkRequisite, want: true},
+		{url: "http://example.com/", kind: domain.LinkNavigation, want: false},
+	} {
+		if got, rule := s.Allowed(tc.url, tc.kind); got != tc.want {
+			t.Errorf("Allowed(%s, %v) = %v (%s), want %v", tc.url, tc.kind, got, rule, tc.want)
+		}
+	}
+
+	// Со стартового http:// локальные файлы не обходятся
+	if got, _ := newScope("http://example.com/", func(cfg *config.Config) {
+		cfg.Schemes = []string{"http", "file"}
+	}).Allowed("file:///srv/site/index.html", domain.LinkNavigation); got {
+		t.Error("file:// URL allowed from an http:// crawl")
+	}
+}
+
+func TestAllowedWithoutStartURL(t *testing.T) {
+	s := newScope("", func(cfg *config.Config) { cfg.ExcludeDomains = []string{"mirror.example.org"} })
+
+	if got, rule := s.Allowed("https://downloads.example.com/file.iso", domain.LinkNavigation); !got {
+		t.Errorf("metalink URL excluded without -url: %s", rule)
+	}
+	if got, _ := s.Allowed("https://mirror.example.org/file.iso", domain.LinkNavigation); got {
+		t.Error("-exclude-domains ignored without -url")
+	}
+}
+
+func TestAllowedFileDirectoryWithoutSlash(t *testing.T) {
+	root := filepath.ToSlash(t.TempDir())
+	if err := os.Mkdir(filepath.Join(root, "site"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := newScope("file://"+root+"/site", nil)
+
+	if got, rule := s.Allowed("file://"+root+"/site/a.html", domain.LinkNavigation); !got {
+		t.Errorf("page inside the start directory excluded: %s", rule)
+	}
+	if got, _ := s.Allowed("file://"+root+"/other.html", domain.LinkNavigation); got {
+		t.Error("page next to the start directory allowed")
+	}
+}
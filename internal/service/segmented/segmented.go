@@ -0,0 +1,236 @@
+package segmented
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"wget-go/internal/config"
+	httpserver "wget-go/internal/delivery/http-server"
+)
+
+const (
+	// segmentAttempts сколько раз пробовать скачать один диапазон
+	segmentAttempts = 3
+	// retryDelay базовая пауза перед повтором диапазона
+	retryDelay = time.Second
+)
+
+// Fetcher скачивает большие файлы несколькими параллельными диапазонами
+// байт в заранее выделенный файл, как это делает aria2
+type Fetcher struct {
+	client   httpserver.RangeClient
+	segments int
+	minSize  int64
+	maxConns int
+}
+
+// New создает загрузчик диапазонов
+func New(cfg *config.Config, client httpserver.RangeClient) *Fetcher {
+	return &Fetcher{
+		client:   client,
+		segments: cfg.Segments,
+		minSize:  int64(cfg.MinSegmentSize),
+		maxConns: cfg.MaxConnsPerHost,
+	}
+}
+
+// Segments возвращает, на сколько диапазонов делить файл размера size.
+// Значение не превышает лимит соединений к хосту
+func (f *Fetcher) Segments(size int64) int {
+	segments := f.segments
+	if f.maxConns > 0 && segments > f.maxConns {
+		segments = f.maxConns
+	}
+	if f.minSize > 0 {
+		if bySize := size / f.minSize; bySize < int64(segments) {
+			segments = int(bySize)
+		}
+	}
+	if segments < 1 {
+		segments = 1
+	}
+	return segments
+}
+
+// segment диапазон байт [start, end] и количество уже записанных байт
+type segment struct {
+	start   int64
+	end     int64
+	written int64
+}
+
+// Fetch скачивает ресурс размера size в path. Валидатор из заголовков
+// пробного запроса (ETag или Last-Modified) передается в If-Range каждого
+// диапазона, чтобы части разных версий ресурса не смешались в одном файле
+func (f *Fetcher) Fetch(ctx context.Context, url, path string, size int64, probe http.Header) error {
+	validator := validatorFrom(probe)
+	segments := split(size, f.Segments(size))
+	log.Printf("Segmented download of %s: %d bytes in %d segments", url, size, len(segments))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	partPath := path + ".part"
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", partPath, err)
+	}
+
+	if err := f.fetchInto(ctx, url, file, size, validator, segments); err != nil {
+		file.Close()
+		os.Remove(partPath)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("close %s: %w", partPath, err)
+	}
+	return os.Rename(partPath, path)
+}
+
+// fetchInto выделяет место под файл, качает диапазоны и проверяет результат
+func (f *Fetcher) fetchInto(ctx context.Context, url string, file *os.File, size int64, validator string, segments []*segment) error {
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("preallocate %d bytes: %w", size, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, seg := range segments {
+		wg.Add(1)
+		go func(index int, seg *segment) {
+			defer wg.Done()
+			if err := f.fetchSegment(ctx, url, file, validator, seg); err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("segment %d (bytes %d-%d): %w", index, seg.start, seg.end, err)
+					cancel()
+				})
+			}
+		}(i, seg)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// Проверяем, что собранный файл совпадает с заявленным размером
+	var total int64
+	for _, seg := range segments {
+		total += seg.written
+	}
+	if total != size {
+		return fmt.Errorf("assembled %d bytes, expected %d", total, size)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	return nil
+}
+
+// fetchSegment качает один диапазон, продолжая с места обрыва при повторах
+func (f *Fetcher) fetchSegment(ctx context.Context, url string, file *os.File, validator string, seg *segment) error {
+	var lastErr error
+	for attempt := 1; attempt <= segmentAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(time.Duration(attempt-1) * retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			log.Printf("Retrying segment bytes %d-%d of %s from offset %d (attempt %d)",
+				seg.start, seg.end, url, seg.start+seg.written, attempt)
+		}
+
+		offset := seg.start + seg.written
+		writer := &countingWriter{w: io.NewOffsetWriter(file, offset)}
+		resp, err := f.client.GetRange(ctx, url, offset, seg.end, validator, writer)
+		seg.written += writer.n
+
+		if err == nil {
+			err = checkValidator(resp, validator)
+		}
+		if err == nil && seg.start+seg.written <= seg.end {
+			err = fmt.Errorf("short segment: got %d of %d bytes", seg.written, seg.end-seg.start+1)
+		}
+		if err == nil {
+			return nil
+		}
+
+		// Эти ошибки повтор не исправит
+		if errors.Is(err, httpserver.ErrRangeIgnored) ||
+			errors.Is(err, httpserver.ErrRangeNotSupported) ||
+			ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// checkValidator сверяет ETag ответа с валидатором, полученным при пробном запросе
+func checkValidator(resp *httpserver.Response, validator string) error {
+	if resp == nil || validator == "" || !isETag(validator) {
+		return nil
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && etag != validator {
+		return fmt.Errorf("%w: ETag changed from %s to %s", httpserver.ErrRangeIgnored, validator, etag)
+	}
+	return nil
+}
+
+// validatorFrom выбирает валидатор для If-Range: сильный ETag или Last-Modified.
+// Слабые ETag в If-Range недопустимы
+func validatorFrom(header http.Header) string {
+	if etag := header.Get("ETag"); isETag(etag) {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// isETag сообщает, является ли значение сильным ETag
+func isETag(value string) bool {
+	return len(value) >= 2 && value[0] == '"'
+}
+
+// split делит size байт на count почти равных диапазонов
+func split(size int64, count int) []*segment {
+	segments := make([]*segment, 0, count)
+	chunk := size / int64(count)
+	for i := 0; i < count; i++ {
+		start := int64(i) * chunk
+		end := start + chunk - 1
+		if i == count-1 {
+			end = size - 1
+		}
+		segments = append(segments, &segment{start: start, end: end})
+	}
+	return segments
+}
+
+// countingWriter считает записанные байты
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -2,9 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
 	"wget-go/internal/domain"
 )
 
+// ErrQuotaExceeded возвращается для задач, не начатых из-за исчерпанной квоты
+var ErrQuotaExceeded = errors.New("download quota exceeded")
+
+// ErrChecksumMismatch возвращается, когда скачанный ресурс не прошел проверку целостности
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // Downloader загружает ресурсы
 type Downloader interface {
 	Download(ctx context.Context, task domain.DownloadTask) (domain.DownloadResult, error)
@@ -12,12 +21,12 @@ type Downloader interface {
 
 // Extractor извлекает ссылки из контента
 type Extractor interface {
-	ExtractLinks(content []byte, baseURL string, contentType string) ([]string, error)
+	ExtractLinks(content []byte, baseURL string, contentType string) ([]domain.Link, error)
 }
 
 // HTMLParser парсит HTML контент
 type HTMLParser interface {
-	Parse(htmlContent []byte, baseURL string) ([]string, error)
+	Parse(htmlContent []byte, baseURL string) ([]domain.Link, error)
 }
 
 // Scheduler управляет процессом скачивания
@@ -34,4 +43,66 @@ type SchedulerStats struct {
 	FailedTasks    int
 	ActiveWorkers  int
 	PendingTasks   int
+	SkippedTasks   int
+	VerifiedFiles  int
+	StopReason     string
+	HostRates      map[string]int
+}
+
+// Quota учитывает объем скачивания и лимиты размера ресурсов
+type Quota interface {
+	Add(n int64)
+	Exceeded() bool
+	Reason() string
+	LimitFor(rt domain.ResourceType) int64
+}
+
+// SegmentedFetcher скачивает большой файл параллельными диапазонами байт
+type SegmentedFetcher interface {
+	Segments(size int64) int
+	Fetch(ctx context.Context, url, path string, size int64, probe http.Header) error
+}
+
+// Verifier проверяет целостность скачанных ресурсов
+type Verifier interface {
+	Expected(ctx context.Context, url string, header http.Header, resourceType domain.ResourceType) []domain.Checksum
+	Verify(expected []domain.Checksum, r io.Reader) error
+}
+
+// SitemapSource сообщает адреса sitemap, объявленные сайтом в robots.txt
+type SitemapSource interface {
+	Sitemaps(url string) []string
+}
+
+// Frontier очередь задач, ожидающих скачивания. Push не блокируется.
+// Выданная Pop задача считается в работе до вызова Done
+type Frontier interface {
+	Push(task domain.DownloadTask)
+	Pop(ctx context.Context) (domain.DownloadTask, bool)
+	Done(task domain.DownloadTask)
+	Snapshot() []domain.DownloadTask
+	Len() int
+	Close()
+}
+
+// Scorer оценивает задачу для обхода best-first: чем больше, тем раньше
+type Scorer interface {
+	Score(task domain.DownloadTask) float64
+}
+
+// Scope определяет границы обхода. Для URL за границами Allowed
+// возвращает описание исключившего его правила
+type Scope interface {
+	Allowed(url string, kind domain.LinkKind) (bool, string)
+}
+
+// URLFilter отбирает URL по правилам -accept-regex, -reject-glob и
+// подобным. Allowed возвращает правило, решившее дело
+type URLFilter interface {
+	Allowed(url string) (bool, string)
+}
+
+// RateReporter сообщает текущие скорости запросов по хостам
+type RateReporter interface {
+	Rates() map[string]int
 }
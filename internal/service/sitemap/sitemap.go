@@ -0,0 +1,242 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"wget-go/internal/config"
+	httpserver "wget-go/internal/delivery/http-server"
+	"wget-go/internal/domain"
+	"wget-go/internal/service"
+)
+
+const (
+	// maxSitemapSize максимальный размер sitemap после распаковки (sitemaps.org)
+	maxSitemapSize = 50 << 20
+	// maxIndexDepth сколько уровней вложенных индексов обходится
+	maxIndexDepth = 2
+	// defaultPriority приоритет страницы без <priority>
+	defaultPriority = 0.5
+	// taskDepth глубина задач из sitemap: как у ссылок со стартовой страницы
+	taskDepth = 1
+)
+
+const (
+	// OrderLastmod сначала недавно измененные страницы
+	OrderLastmod = "lastmod"
+	// OrderPriority сначала страницы с большим <priority>
+	OrderPriority = "priority"
+)
+
+// lastmodLayouts форматы W3C Datetime, допустимые в <lastmod>
+var lastmodLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// document sitemap (<urlset>) или индекс sitemap (<sitemapindex>)
+type document struct {
+	XMLName  xml.Name
+	URLs     []entryXML `xml:"url"`
+	Sitemaps []entryXML `xml:"sitemap"`
+}
+
+type entryXML struct {
+	Loc      string `xml:"loc"`
+	LastMod  string `xml:"lastmod"`
+	Priority string `xml:"priority"`
+}
+
+// Entry страница из sitemap
+type Entry struct {
+	URL      string
+	LastMod  time.Time // Нулевое значение - дата не указана
+	Priority float64
+	Sitemap  string // Sitemap, в котором найдена страница
+}
+
+// Discoverer находит sitemap сайта и собирает из них страницы
+type Discoverer struct {
+	client httpserver.Client
+	source service.SitemapSource // nil - robots.txt не читается
+	order  string
+}
+
+// New создает поиск sitemap
+func New(cfg *config.Config, client httpserver.Client, source service.SitemapSource) *Discoverer {
+	return &Discoverer{
+		client: client,
+		source: source,
+		order:  cfg.SitemapOrder,
+	}
+}
+
+// Discover читает sitemap из robots.txt и /sitemap.xml сайта и возвращает
+// задачи для найденных страниц в порядке -sitemap-order
+func (d *Discoverer) Discover(ctx context.Context, siteURL string) []domain.DownloadTask {
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return nil
+	}
+	origin := &url.URL{Scheme: site.Scheme, Host: site.Host}
+
+	var locations []string
+	if d.source != nil {
+		locations = append(locations, d.source.Sitemaps(siteURL)...)
+	}
+	locations = append(locations, origin.JoinPath("sitemap.xml").String())
+
+	seenSitemaps := make(map[string]bool)
+	seenPages := make(map[string]bool)
+	var entries []Entry
+	for _, location := range locations {
+		sitemapURL, err := origin.Parse(location)
+		if err != nil {
+			continue
+		}
+		entries = d.collect(ctx, sitemapURL.String(), site, 0, seenSitemaps, seenPages, entries)
+	}
+
+	d.arrange(entries)
+	log.Printf("Sitemaps: found %d URLs in %d sitemaps", len(entries), len(seenSitemaps))
+
+	tasks := make([]domain.DownloadTask, 0, len(entries))
+	for _, entry := range entries {
+		tasks = append(tasks, domain.DownloadTask{
+			URL:       entry.URL,
+			Depth:     taskDepth,
+			ParentURL: entry.Sitemap,
+			Priority:  entry.Priority,
+		})
+	}
+	return tasks
+}
+
+// collect загружает sitemap и добавляет его страницы к entries, обходя вложенные индексы
+func (d *Discoverer) collect(ctx context.Context, sitemapURL string, site *url.URL, level int, seenSitemaps, seenPages map[string]bool, entries []Entry) []Entry {
+	if seenSitemaps[sitemapURL] {
+		return entries
+	}
+	seenSitemaps[sitemapURL] = true
+
+	doc, err := d.fetch(ctx, sitemapURL)
+	if err != nil {
+		log.Printf("Sitemap %s skipped: %v", sitemapURL, err)
+		return entries
+	}
+
+	switch doc.XMLName.Local {
+	case "sitemapindex":
+		if level >= maxIndexDepth {
+			log.Printf("Sitemap index %s skipped: nested deeper than %d levels", sitemapURL, maxIndexDepth)
+			return entries
+		}
+		for _, child := range doc.Sitemaps {
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				entries = d.collect(ctx, loc, site, level+1, seenSitemaps, seenPages, entries)
+			}
+		}
+	case "urlset":
+		for _, item := range doc.URLs {
+			entry, ok := newEntry(item, sitemapURL, site)
+			if !ok || seenPages[entry.URL] {
+				continue
+			}
+			seenPages[entry.URL] = true
+			entries = append(entries, entry)
+		}
+	default:
+		log.Printf("Sitemap %s skipped: unexpected root element <%s>", sitemapURL, doc.XMLName.Local)
+	}
+	return entries
+}
+
+// fetch загружает и разбирает sitemap, распаковывая gzip
+func (d *Discoverer) fetch(ctx context.Context, sitemapURL string) (*document, error) {
+	resp, err := d.client.Get(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader = bytes.NewReader(resp.Body)
+	// Сжатый файл (sitemap.xml.gz) определяем по сигнатуре: заголовок
+	// Content-Type для него часто указан неверно
+	if bytes.HasPrefix(resp.Body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("gunzip: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	content, err := io.ReadAll(io.LimitReader(body, maxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read sitemap: %w", err)
+	}
+	if len(content) > maxSitemapSize {
+		return nil, fmt.Errorf("sitemap exceeds %d bytes", maxSitemapSize)
+	}
+
+	var doc document
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parse sitemap: %w", err)
+	}
+	return &doc, nil
+}
+
+// newEntry проверяет страницу sitemap: допускаются только адреса того же хоста, что и сайт
+func newEntry(item entryXML, sitemapURL string, site *url.URL) (Entry, bool) {
+	loc, err := url.Parse(strings.TrimSpace(item.Loc))
+	if err != nil || !loc.IsAbs() || !strings.EqualFold(loc.Hostname(), site.Hostname()) {
+		return Entry{}, false
+	}
+	loc.Fragment = ""
+
+	entry := Entry{
+		URL:      loc.String(),
+		Priority: defaultPriority,
+		Sitemap:  sitemapURL,
+	}
+
+	if value := strings.TrimSpace(item.LastMod); value != "" {
+		for _, layout := range lastmodLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				entry.LastMod = t
+				break
+			}
+		}
+	}
+	if value := strings.TrimSpace(item.Priority); value != "" {
+		if p, err := strconv.ParseFloat(value, 64); err == nil && p >= 0 && p <= 1 {
+			entry.Priority = p
+		}
+	}
+	return entry, true
+}
+
+// arrange упорядочивает страницы согласно -sitemap-order, без него сохраняется порядок файлов
+func (d *Discoverer) arrange(entries []Entry) {
+	switch d.order {
+	case OrderLastmod:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].LastMod.After(entries[j].LastMod)
+		})
+	case OrderPriority:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Priority > entries[j].Priority
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package urlfilter
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"wget-go/internal/config"
+)
+
+// rule правило фильтра с именем флага для сообщений
+type rule struct {
+	flag    string
+	pattern string
+	match   func(u *url.URL, rawURL string) bool
+}
+
+func (r rule) String() string {
+	return r.flag + " " + r.pattern
+}
+
+// Filter отбирает URL по правилам из флагов и файла конфигурации.
+// Правила проверяются в таком порядке:
+//
+//  1. -exclude-directories: URL в исключенном каталоге отбрасывается
+//  2. -reject-regex и -reject-glob: подходящий URL отбрасывается
+//  3. -include-directories, если заданы: URL должен лежать в одном из каталогов
+//  4. -accept-regex и -accept-glob, если заданы: URL должен подходить под один из шаблонов
+//
+// Отбрасывающие правила важнее разрешающих. Регулярные выражения и
+// шаблоны сравниваются с нормализованным URL
+type Filter struct {
+	excludeDirs []rule
+	reject      []rule
+	includeDirs []rule
+	accept      []rule
+}
+
+// New создает фильтр по конфигурации. Регулярные выражения проверены при
+// валидации конфигурации
+func New(cfg *config.Config) *Filter {
+	f := &Filter{}
+	for _, dir := range cfg.ExcludeDirectories {
+		f.excludeDirs = append(f.excludeDirs, directoryRule("-exclude-directories", dir))
+	}
+	for _, pattern := range cfg.RejectRegex {
+		f.reject = append(f.reject, regexRule("-reject-regex", pattern))
+	}
+	for _, pattern := range cfg.RejectGlob {
+		f.reject = append(f.reject, globRule("-reject-glob", pattern))
+	}
+	for _, dir := range cfg.IncludeDirectories {
+		f.includeDirs = append(f.includeDirs, directoryRule("-include-directories", dir))
+	}
+	for _, pattern := range cfg.AcceptRegex {
+		f.accept = append(f.accept, regexRule("-accept-regex", pattern))
+	}
+	for _, pattern := range cfg.AcceptGlob {
+		f.accept = append(f.accept, globRule("-accept-glob", pattern))
+	}
+	return f
+}
+
+// Allowed проверяет URL и возвращает правило, которое решило дело
+func (f *Filter) Allowed(rawURL string) (bool, string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false, "malformed URL"
+	}
+
+	if r, ok := firstMatch(f.excludeDirs, parsed, rawURL); ok {
+		return false, "excluded by " + r.String()
+	}
+	if r, ok := firstMatch(f.reject, parsed, rawURL); ok {
+		return false, "rejected by " + r.String()
+	}
+
+	var accepted []string
+	if len(f.includeDirs) > 0 {
+		r, ok := firstMatch(f.includeDirs, parsed, rawURL)
+		if !ok {
+			return false, "not in any of " + describe(f.includeDirs)
+		}
+		accepted = append(accepted, r.String())
+	}
+	if len(f.accept) > 0 {
+		r, ok := firstMatch(f.accept, parsed, rawURL)
+		if !ok {
+			return false, "matches none of " + describe(f.accept)
+		}
+		accepted = append(accepted, r.String())
+	}
+
+	if len(accepted) == 0 {
+		return true, "no filter rejects it"
+	}
+	return true, "accepted by " + strings.Join(accepted, " and ")
+}
+
+func firstMatch(rules []rule, u *url.URL, rawURL string) (rule, bool) {
+	for _, r := range rules {
+		if r.match(u, rawURL) {
+			return r, true
+		}
+	}
+	return rule{}, false
+}
+
+func describe(rules []rule) string {
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func regexRule(flag, pattern string) rule {
+	re := regexp.MustCompile(pattern)
+	return rule{
+		flag:    flag,
+		pattern: pattern,
+		match: func(_ *url.URL, rawURL string) bool {
+			return re.MatchString(rawURL)
+		},
+	}
+}
+
+// globRule сравнивает URL с шаблоном, где * означает любую
+// последовательность символов, включая /, а остальные символы, в том
+// числе ?, совпадают буквально. Шаблон, начинающийся с /, сравнивается с
+// путем и запросом URL, остальные - с URL целиком
+func globRule(flag, pattern string) rule {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re := regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+
+	return rule{
+		flag:    flag,
+		pattern: pattern,
+		match: func(u *url.URL, rawURL string) bool {
+			if !strings.HasPrefix(pattern, "/") {
+				return re.MatchString(rawURL)
+			}
+			target := u.EscapedPath()
+			if target == "" {
+				target = "/"
+			}
+			if u.RawQuery != "" {
+				target += "?" + u.RawQuery
+			}
+			return re.MatchString(target)
+		},
+	}
+}
+
+// directoryRule проверяет, лежит ли URL в каталоге dir или ниже. Каталог
+// с символами *, ? или [ сравнивается по path.Match с каждым каталогом
+// пути URL: /docs/*/api подходит для /docs/v2/api/index.html
+func directoryRule(flag, dir string) rule {
+	cleaned := "/" + strings.Trim(dir, "/")
+	wildcard := strings.ContainsAny(cleaned, "*?[")
+
+	return rule{
+		flag:    flag,
+		pattern: dir,
+		match: func(u *url.URL, _ string) bool {
+			// Сам каталог без слеша на конце (/docs) тоже в нем
+			if trimmed := strings.TrimSuffix(u.Path, "/"); trimmed != "" {
+				if matched, _ := path.Match(cleaned, trimmed); matched {
+					return true
+				}
+			}
+
+			urlDir := u.Path
+			if urlDir == "" {
+				urlDir = "/"
+			}
+			urlDir = urlDir[:strings.LastIndex(urlDir, "/")+1]
+
+			if !wildcard {
+				return cleaned == "/" || strings.HasPrefix(urlDir, cleaned+"/")
+			}
+			for i := 1; i < len(urlDir); i++ {
+				if urlDir[i] != '/' {
+					continue
+				}
+				if matched, _ := path.Match(cleaned, urlDir[:i]); matched {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
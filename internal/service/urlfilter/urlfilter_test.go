@@ -0,0 +1,163 @@
+package urlfilter
+
+import (
+	"strings"
+	"testing"
+	"wget-go/internal/config"
+)
+
+func newFilter(configure func(cfg *config.Config)) *Filter {
+	cfg := config.Default()
+	configure(cfg)
+	return New(cfg)
+}
+
+func TestAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		configure func(cfg *config.Config)
+		url       string
+		want      bool
+		reason    string
+	}{
+		{
+			name:      "no rules",
+			configure: func(cfg *config.Config) {},
+			url:       "https://example.com/any",
+			want:      true,
+			reason:    "no filter rejects it",
+		},
+		{
+			name:      "reject regex",
+			configure: func(cfg *config.Config) { cfg.RejectRegex = []string{`\.(zip|iso)$`} },
+			url:       "https://example.com/dist/image.iso",
+			reason:    `rejected by -reject-regex \.(zip|iso)$`,
+		},
+		{
+			name:      "accept regex misses",
+			configure: func(cfg *config.Config) { cfg.AcceptRegex = []string{`/docs/`, `\.pdf$`} },
+			url:       "https://example.com/blog/post.html",
+			reason:    `matches none of -accept-regex /docs/, -accept-regex \.pdf$`,
+		},
+		{
+			name:      "path glob matches path and query",
+			configure: func(cfg *config.Config) { cfg.RejectGlob = []string{"/search?*"} },
+			url:       "https://example.com/search?q=go",
+			reason:    "rejected by -reject-glob /search?*",
+		},
+		{
+			name:      "path glob ? is literal",
+			configure: func(cfg *config.Config) { cfg.RejectGlob = []string{"/search?*"} },
+			url:       "https://example.com/searches",
+			want:      true,
+		},
+		{
+			name:      "path glob star crosses slashes",
+			configure: func(cfg *config.Config) { cfg.AcceptGlob = []string{"/docs/*.html"} },
+			url:       "https://example.com/docs/v2/api/index.html",
+			want:      true,
+			reason:    "accepted by -accept-glob /docs/*.html",
+		},
+		{
+			name:      "path glob ignores host",
+			configure: func(cfg *config.Config) { cfg.AcceptGlob = []string{"/docs/*"} },
+			url:       "https://docs.example.com/blog/docs/a.html",
+		},
+		{
+			name:      "full URL glob",
+			configure: func(cfg *config.Config) { cfg.RejectGlob = []string{"https://cdn.example.com/*"} },
+			url:       "https://cdn.example.com/app.js",
+			reason:    "rejected by -reject-glob https://cdn.example.com/*",
+		},
+		{
+			name:      "full URL glob is anchored",
+			configure: func(cfg *config.Config) { cfg.RejectGlob = []string{"https://cdn.example.com/*"} },
+			url:       "https://example.com/?next=https://cdn.example.com/app.js",
+			want:      true,
+		},
+		{
+			name:      "exclude directory and below",
+			configure: func(cfg *config.Config) { cfg.ExcludeDirectories = []string{"/docs/old/"} },
+			url:       "https://example.com/docs/old/v1/index.html",
+			reason:    "excluded by -exclude-directories /docs/old/",
+		},
+		{
+			name:      "exclude directory itself without slash",
+			configure: func(cfg *config.Config) { cfg.ExcludeDirectories = []string{"/docs/old"} },
+			url:       "https://example.com/docs/old",
+		},
+		{
+			name:      "exclude directory is not a prefix match",
+			configure: func(cfg *config.Config) { cfg.ExcludeDirectories = []string{"/docs/old"} },
+			url:       "https://example.com/docs/older/index.html",
+			want:      true,
+		},
+		{
+			name:      "include directory with wildcard",
+			configure: func(cfg *config.Config) { cfg.IncludeDirectories = []string{"/docs/*/api"} },
+			url:       "https://example.com/docs/v2/api/index.html",
+			want:      true,
+			reason:    "accepted by -include-directories /docs/*/api",
+		},
+		{
+			name:      "wildcard matches one path element",
+			configure: func(cfg *config.Config) { cfg.IncludeDirectories = []string{"/docs/*/api"} },
+			url:       "https://example.com/docs/v2/beta/api/index.html",
+			reason:    "not in any of -include-directories /docs/*/api",
+		},
+		{
+			name: "exclude wins over include",
+			configure: func(cfg *config.Config) {
+				cfg.IncludeDirectories = []string{"/docs"}
+				cfg.ExcludeDirectories = []string{"/docs/private"}
+			},
+			url:    "https://example.com/docs/private/keys.html",
+			reason: "excluded by -exclude-directories /docs/private",
+		},
+		{
+			name: "reject wins over accept",
+			configure: func(cfg *config.Config) {
+				cfg.AcceptGlob = []string{"*.pdf"}
+				cfg.RejectRegex = []string{`draft`}
+			},
+			url:    "https://example.com/papers/draft.pdf",
+			reason: "rejected by -reject-regex draft",
+		},
+		{
+			name: "include and accept both required",
+			configure: func(cfg *config.Config) {
+				cfg.IncludeDirectories = []string{"/papers"}
+				cfg.AcceptGlob = []string{"*.pdf"}
+			},
+			url:    "https://example.com/papers/final.pdf",
+			want:   true,
+			reason: "accepted by -include-directories /papers and -accept-glob *.pdf",
+		},
+		{
+			name: "include passes but accept fails",
+			configure: func(cfg *config.Config) {
+				cfg.IncludeDirectories = []string{"/papers"}
+				cfg.AcceptGlob = []string{"*.pdf"}
+			},
+			url:    "https://example.com/papers/index.html",
+			reason: "matches none of -accept-glob *.pdf",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason := newFilter(tc.configure).Allowed(tc.url)
+			if got != tc.want {
+				t.Fatalf("Allowed(%s) = %v (%s), want %v", tc.url, got, reason, tc.want)
+			}
+			if tc.reason != "" && reason != tc.reason {
+				t.Fatalf("Allowed(%s) reason %q, want %q", tc.url, reason, tc.reason)
+			}
+		})
+	}
+}
+
+func TestAllowedMalformedURL(t *testing.T) {
+	got, reason := newFilter(func(cfg *config.Config) {}).Allowed("http://exa mple.com/%zz")
+	if got || !strings.Contains(reason, "malformed") {
+		t.Fatalf("Allowed = %v (%s), want a malformed URL rejection", got, reason)
+	}
+}
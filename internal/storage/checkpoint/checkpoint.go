@@ -0,0 +1,315 @@
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+// stateVersion версия формата файла состояния
+const stateVersion = 1
+
+// stateFile формат файла состояния
+type stateFile struct {
+	Version    int               `json:"version"`
+	ConfigHash string            `json:"config_hash"`
+	Settings   map[string]string `json:"settings"`
+	SavedAt    time.Time         `json:"saved_at"`
+	Pending    []taskRecord      `json:"pending"`
+	Visited    []string          `json:"visited"`
+	Outcomes   []outcomeRecord   `json:"outcomes"`
+}
+
+// taskRecord задача в файле состояния
+type taskRecord struct {
+	URL       string           `json:"url"`
+	Depth     int              `json:"depth"`
+	Type      string           `json:"type"`
+	ParentURL string           `json:"parent_url,omitempty"`
+	Mirrors   []string         `json:"mirrors,omitempty"`
+	Checksums []checksumRecord `json:"checksums,omitempty"`
+	FileName  string           `json:"file_name,omitempty"`
+	Size      int64            `json:"size,omitempty"`
+	Requisite bool             `json:"requisite,omitempty"`
+	Position  int              `json:"position,omitempty"`
+	Priority  float64          `json:"priority,omitempty"`
+	Score     float64          `json:"score,omitempty"`
+	Seq       int64            `json:"seq"`
+}
+
+type checksumRecord struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+	Source    string `json:"source"`
+}
+
+// outcomeRecord итог обработки URL в файле состояния
+type outcomeRecord struct {
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	FilePath string `json:"file_path,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Store сохраняет состояние обхода в JSON файл и загружает его при
+// продолжении. Вместе с состоянием хранятся настройки, определяющие
+// набор URL обхода: продолжить можно только с теми же настройками
+type Store struct {
+	path     string
+	settings map[string]string
+	hash     string
+}
+
+// New создает хранилище состояния в файле path для конфигурации cfg
+func New(path string, cfg *config.Config) *Store {
+	settings := crawlSettings(cfg)
+	return &Store{
+		path:     path,
+		settings: settings,
+		hash:     hashSettings(settings),
+	}
+}
+
+// crawlSettings настройки, от которых зависит набор скачиваемых URL и
+// место их сохранения, по именам флагов. Скорость, таймауты и кэш на
+// результат обхода не влияют и могут меняться между запусками
+func crawlSettings(cfg *config.Config) map[string]string {
+	return map[string]string{
+		"url":             cfg.URL,
+		"output":          cfg.OutputDir,
+		"depth":           strconv.Itoa(cfg.MaxDepth),
+		"respect-robots":  strconv.FormatBool(cfg.RespectRobots),
+		"sitemaps":        strconv.FormatBool(cfg.Sitemaps),
+		"sitemaps-only":   strconv.FormatBool(cfg.SitemapsOnly),
+		"metalink":        cfg.Metalink,
+		"page-requisites": strconv.FormatBool(cfg.PageRequisites),
+		"requisite-host":  strings.Join(sortedCopy(cfg.RequisiteHosts), ","),
+		"span-hosts":      strconv.FormatBool(cfg.SpanHosts),
+		"domains":         strings.Join(sortedCopy(cfg.Domains), ","),
+		"exclude-domains": strings.Join(sortedCopy(cfg.ExcludeDomains), ","),
+		"no-parent":       strconv.FormatBool(cfg.NoParent),
+		"schemes":         strings.Join(sortedCopy(cfg.Schemes), ","),
+		"ports":           joinPorts(cfg.Ports),
+
+		"accept-regex":        strings.Join(sortedCopy(cfg.AcceptRegex), ","),
+		"reject-regex":        strings.Join(sortedCopy(cfg.RejectRegex), ","),
+		"accept-glob":         strings.Join(sortedCopy(cfg.AcceptGlob), ","),
+		"reject-glob":         strings.Join(sortedCopy(cfg.RejectGlob), ","),
+		"include-directories": strings.Join(sortedCopy(cfg.IncludeDirectories), ","),
+		"exclude-directories": strings.Join(sortedCopy(cfg.ExcludeDirectories), ","),
+	}
+}
+
+// hashSettings вычисляет хэш настроек, не зависящий от порядка ключей
+func hashSettings(settings map[string]string) string {
+	hash := sha256.New()
+	for _, name := range sortedKeys(settings) {
+		fmt.Fprintf(hash, "%s=%s\n", name, settings[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Path возвращает путь к файлу состояния
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load читает сохраненное состояние. Возвращает nil без ошибки, если
+// файла еще нет, и ошибку, если состояние сохранено с другими настройками
+func (s *Store) Load() (*domain.CrawlState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read crawl state: %w", err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse crawl state %s: %w", s.path, err)
+	}
+	if file.Version != stateVersion {
+		return nil, fmt.Errorf("crawl state %s has unsupported version %d", s.path, file.Version)
+	}
+	if file.ConfigHash != s.hash {
+		return nil, fmt.Errorf("crawl state %s was saved with different settings (%s); "+
+			"restore them or use a new state file", s.path, s.describeChanges(file.Settings))
+	}
+
+	state := &domain.CrawlState{
+		Visited: file.Visited,
+	}
+	for _, record := range file.Pending {
+		state.Pending = append(state.Pending, record.task())
+	}
+	for _, record := range file.Outcomes {
+		state.Outcomes = append(state.Outcomes, domain.URLOutcome{
+			URL:      record.URL,
+			Status:   domain.OutcomeStatus(record.Status),
+			FilePath: record.FilePath,
+			Error:    record.Error,
+		})
+	}
+	return state, nil
+}
+
+// describeChanges перечисляет отличия сохраненных настроек от текущих
+func (s *Store) describeChanges(saved map[string]string) string {
+	var changes []string
+	for _, name := range sortedKeys(s.settings) {
+		previous, ok := saved[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("-%s is not recorded", name))
+			continue
+		}
+		if previous != s.settings[name] {
+			changes = append(changes, fmt.Sprintf("-%s was %q, now %q", name, previous, s.settings[name]))
+		}
+	}
+	if len(changes) == 0 {
+		return "settings hash differs"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// Save атомарно записывает состояние: через временный файл и переименование,
+// чтобы прерывание во время записи не испортило предыдущий снимок
+func (s *Store) Save(state *domain.CrawlState) error {
+	file := stateFile{
+		Version:    stateVersion,
+		ConfigHash: s.hash,
+		Settings:   s.settings,
+		SavedAt:    time.Now().UTC(),
+		Pending:    make([]taskRecord, 0, len(state.Pending)),
+		Visited:    append([]string(nil), state.Visited...),
+		Outcomes:   make([]outcomeRecord, 0, len(state.Outcomes)),
+	}
+	sort.Strings(file.Visited)
+	for _, task := range state.Pending {
+		file.Pending = append(file.Pending, newTaskRecord(task))
+	}
+	for _, outcome := range state.Outcomes {
+		file.Outcomes = append(file.Outcomes, outcomeRecord{
+			URL:      outcome.URL,
+			Status:   string(outcome.Status),
+			FilePath: outcome.FilePath,
+			Error:    outcome.Error,
+		})
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("encode crawl state: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+func newTaskRecord(task domain.DownloadTask) taskRecord {
+	record := taskRecord{
+		URL:       task.URL,
+		Depth:     task.Depth,
+		Type:      task.Type.String(),
+		ParentURL: task.ParentURL,
+		Mirrors:   task.Mirrors,
+		FileName:  task.FileName,
+		Size:      task.Size,
+		Requisite: task.Kind == domain.LinkRequisite,
+		Position:  task.Position,
+		Priority:  task.Priority,
+		Score:     task.Score,
+		Seq:       task.Seq,
+	}
+	for _, checksum := range task.Checksums {
+		record.Checksums = append(record.Checksums, checksumRecord(checksum))
+	}
+	return record
+}
+
+func (r taskRecord) task() domain.DownloadTask {
+	resourceType, _ := domain.ParseResourceType(r.Type)
+	task := domain.DownloadTask{
+		URL:       r.URL,
+		Depth:     r.Depth,
+		Type:      resourceType,
+		ParentURL: r.ParentURL,
+		Mirrors:   r.Mirrors,
+		FileName:  r.FileName,
+		Size:      r.Size,
+		Position:  r.Position,
+		Priority:  r.Priority,
+		Score:     r.Score,
+		Seq:       r.Seq,
+	}
+	if r.Requisite {
+		task.Kind = domain.LinkRequisite
+	}
+	for _, checksum := range r.Checksums {
+		task.Checksums = append(task.Checksums, domain.Checksum(checksum))
+	}
+	return task
+}
+
+// writeFileAtomic записывает файл через временный файл и переименование
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create crawl state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write crawl state: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write crawl state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write crawl state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write crawl state: %w", err)
+	}
+	return nil
+}
+
+// joinPorts записывает порты по возрастанию через запятую
+func joinPorts(ports []int) string {
+	sorted := append([]int(nil), ports...)
+	sort.Ints(sorted)
+	parts := make([]string, len(sorted))
+	for i, port := range sorted {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
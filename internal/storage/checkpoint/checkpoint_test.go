@@ -0,0 +1,142 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"wget-go/internal/config"
+	"wget-go/internal/domain"
+)
+
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.URL = "https://example.com/docs/"
+	cfg.MaxDepth = 3
+	cfg.RejectGlob = []string{"*.zip", "*.iso"}
+	return cfg
+}
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "crawl.json")
+	store := New(path, testConfig())
+
+	if state, err := store.Load(); state != nil || err != nil {
+		t.Fatalf("Load without a file = %v, %v; want nil, nil", state, err)
+	}
+
+	saved := &domain.CrawlState{
+		Pending: []domain.DownloadTask{
+			{
+				URL:       "https://example.com/docs/a.html",
+				Depth:     1,
+				Type:      domain.ResourceHTML,
+				ParentURL: "https://example.com/docs/",
+				Position:  2,
+				Seq:       5,
+			},
+			{
+				URL:       "https://example.com/docs/logo.png",
+				Depth:     1,
+				Type:      domain.ResourceImage,
+				Kind:      domain.LinkRequisite,
+				Mirrors:   []string{"https://mirror.example.org/logo.png"},
+				Checksums: []domain.Checksum{{Algorithm: "sha-256", Value: "ab", Source: "metalink"}},
+				FileName:  "img/logo.png",
+				Size:      1024,
+				Priority:  0.5,
+				Score:     1.5,
+				Seq:       6,
+			},
+		},
+		Visited: []string{"https://example.com/docs/b.html", "https://example.com/docs/"},
+		Outcomes: []domain.URLOutcome{
+			{URL: "https://example.com/docs/", Status: domain.OutcomeCompleted, FilePath: "docs/index.html"},
+			{URL: "https://example.com/docs/b.html", Status: domain.OutcomeFailed, Error: "404 Not Found"},
+		},
+	}
+	if err := store.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// Продолжение с тем же конфигом, в котором изменились только настройки скорости
+	cfg := testConfig()
+	cfg.RejectGlob = []string{"*.iso", "*.zip"}
+	cfg.Workers = 16
+	loaded, err := New(path, cfg).Load()
+	if err != nil {
+		t.Fatalf("Load with equivalent settings: %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded.Pending, saved.Pending) {
+		t.Errorf("pending tasks:\n got %+v\nwant %+v", loaded.Pending, saved.Pending)
+	}
+	if want := []string{"https://example.com/docs/", "https://example.com/docs/b.html"}; !reflect.DeepEqual(loaded.Visited, want) {
+		t.Errorf("visited = %v, want %v", loaded.Visited, want)
+	}
+	if !reflect.DeepEqual(loaded.Outcomes, saved.Outcomes) {
+		t.Errorf("outcomes:\n got %+v\nwant %+v", loaded.Outcomes, saved.Outcomes)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".tmp-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left after Save: %v", leftovers)
+	}
+}
+
+func TestLoadRefusesChangedSettings(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		configure func(cfg *config.Config)
+		want      string
+	}{
+		{
+			name:      "start URL",
+			configure: func(cfg *config.Config) { cfg.URL = "https://example.com/blog/" },
+			want:      `-url was "https://example.com/docs/", now "https://example.com/blog/"`,
+		},
+		{
+			name:      "depth",
+			configure: func(cfg *config.Config) { cfg.MaxDepth = 5 },
+			want:      `-depth was "3", now "5"`,
+		},
+		{
+			name:      "reject glob",
+			configure: func(cfg *config.Config) { cfg.RejectGlob = []string{"*.zip"} },
+			want:      `-reject-glob was "*.iso,*.zip", now "*.zip"`,
+		},
+		{
+			name:      "ports",
+			configure: func(cfg *config.Config) { cfg.Ports = []int{8080, 80} },
+			want:      `-ports was "", now "80,8080"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "crawl.json")
+			if err := New(path, testConfig()).Save(&domain.CrawlState{Visited: []string{"https://example.com/docs/"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := testConfig()
+			tc.configure(cfg)
+			state, err := New(path, cfg).Load()
+			if err == nil {
+				t.Fatalf("Load with changed settings returned state %+v", state)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error %q does not mention %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path, testConfig()).Load(); err == nil || !strings.Contains(err.Error(), "unsupported version 99") {
+		t.Fatalf("Load = %v, want an unsupported version error", err)
+	}
+}
@@ -26,6 +26,10 @@ func (fm *FileManagerImpl) Save(filePath string, content []byte) error {
 	return os.WriteFile(filePath, content, 0644)
 }
 
+func (fm *FileManagerImpl) Load(filePath string) ([]byte, error) {
+	return os.ReadFile(filePath)
+}
+
 func (fm *FileManagerImpl) Exists(filePath string) bool {
 	_, err := os.Stat(filePath)
 	return err == nil
@@ -66,6 +66,44 @@ func (lr *LinkRewriterImpl) RewriteCSS(cssContent []byte, baseURL string) ([]byt
 	return cssContent, nil
 }
 
+// RewriteRenamed переписывает ссылки исходного HTML страницы pageURL,
+// сохраненной в pagePath, так же как RewriteHTML, но ссылки на ресурсы,
+// переименованные после сохранения страницы (Content-Disposition), ведут на
+// фактический файл. renames отображает абсолютный URL ресурса без
+// фрагмента в его локальный путь
+func (lr *LinkRewriterImpl) RewriteRenamed(originalHTML []byte, pageURL, pagePath string, renames map[string]string) []byte {
+	pageDir := filepath.Dir(pagePath)
+	attrRegex := regexp.MustCompile(`(href|src)=["']([^"']+)["']`)
+
+	content := attrRegex.ReplaceAllStringFunc(string(originalHTML), func(match string) string {
+		parts := attrRegex.FindStringSubmatch(match)
+		if len(parts) < 3 || !lr.shouldRewrite(parts[2]) {
+			return match
+		}
+
+		absoluteURL, err := lr.pathResolver.ResolveAbsoluteURL(pageURL, parts[2])
+		if err != nil {
+			return match
+		}
+
+		key, _, _ := strings.Cut(absoluteURL, "#")
+		targetPath, renamed := renames[key]
+		if !renamed {
+			if targetPath, err = lr.pathResolver.URLToLocalPath(absoluteURL); err != nil {
+				return match
+			}
+		}
+
+		relativePath, err := filepath.Rel(pageDir, targetPath)
+		if err != nil {
+			return match
+		}
+		return parts[1] + `="` + filepath.ToSlash(relativePath) + `"`
+	})
+
+	return []byte(content)
+}
+
 func (lr *LinkRewriterImpl) urlToRelativePath(originalURL, baseURL string) (string, error) {
 	// Преобразуем относительный URL в абсолютный
 	absoluteURL, err := lr.pathResolver.ResolveAbsoluteURL(baseURL, originalURL)
@@ -0,0 +1,28 @@
+package link_rewriter
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"wget-go/internal/storage/path_resolver"
+)
+
+func TestRewriteRenamedKeepsQueryDownloadsApart(t *testing.T) {
+	baseDir := t.TempDir()
+	rewriter := New(path_resolver.New(baseDir))
+
+	page := []byte(`<a href="download?id=42">first</a> <a href="/download?id=43#top">second</a> <img src="logo.png">`)
+	renames := map[string]string{
+		"http://example.com/download?id=42": filepath.Join(baseDir, "example.com", "report-42.pdf"),
+		"http://example.com/download?id=43": filepath.Join(baseDir, "example.com", "report-43.pdf"),
+	}
+
+	fixed := string(rewriter.RewriteRenamed(page, "http://example.com/page.html",
+		filepath.Join(baseDir, "example.com", "page.html"), renames))
+
+	for _, want := range []string{`href="report-42.pdf"`, `href="report-43.pdf"`, `src="logo.png"`} {
+		if !strings.Contains(fixed, want) {
+			t.Errorf("rewritten page has no %s:\n%s", want, fixed)
+		}
+	}
+}
@@ -1,25 +1,100 @@
 package path_resolver
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"mime"
 	"net/url"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
+// dataDir каталог для декодированных data: ресурсов
+const dataDir = "data"
+
 type PathResolverImpl struct {
 	baseDir string
+
+	mu        sync.RWMutex
+	overrides map[string]string // URL -> путь, заданный именем из Content-Disposition
+	claimed   map[string]string // Путь из Content-Disposition -> URL, которому он отдан
 }
 
 func New(baseDir string) *PathResolverImpl {
-	return &PathResolverImpl{baseDir: baseDir}
+	return &PathResolverImpl{
+		baseDir:   baseDir,
+		overrides: make(map[string]string),
+		claimed:   make(map[string]string),
+	}
 }
 
 func (pr *PathResolverImpl) URLToLocalPath(rawURL string) (string, error) {
+	pr.mu.RLock()
+	override, exists := pr.overrides[overrideKey(rawURL)]
+	pr.mu.RUnlock()
+
+	if exists {
+		return override, nil
+	}
+
+	return pr.defaultLocalPath(rawURL)
+}
+
+// UseFilename сохраняет ресурс под указанным именем в каталоге, выведенном из URL.
+// Последующие вызовы URLToLocalPath для этого URL возвращают новый путь.
+// Если имя в каталоге уже отдано другому URL (download?id=1 и download?id=2
+// присылают report.pdf), к нему добавляется номер: report.1.pdf, report.2.pdf
+func (pr *PathResolverImpl) UseFilename(rawURL, filename string) (string, error) {
+	defaultPath, err := pr.defaultLocalPath(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	key := overrideKey(rawURL)
+	dir := filepath.Dir(defaultPath)
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filename, ext)
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	localPath := filepath.Join(dir, filename)
+	for n := 1; ; n++ {
+		owner, taken := pr.claimed[localPath]
+		if !taken || owner == key {
+			break
+		}
+		localPath = filepath.Join(dir, fmt.Sprintf("%s.%d%s", stem, n, ext))
+	}
+
+	if previous, exists := pr.overrides[key]; exists && previous != localPath {
+		delete(pr.claimed, previous)
+	}
+	pr.claimed[localPath] = key
+	pr.overrides[key] = localPath
+
+	return localPath, nil
+}
+
+// overrideKey нормализует URL для поиска переопределений
+func overrideKey(rawURL string) string {
+	key, _, _ := strings.Cut(rawURL, "#")
+	return key
+}
+
+// defaultLocalPath строит путь только по URL
+func (pr *PathResolverImpl) defaultLocalPath(rawURL string) (string, error) {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return "", err
 	}
 
+	if strings.EqualFold(parsed.Scheme, "data") {
+		return pr.dataURLToLocalPath(rawURL), nil
+	}
+
 	// Получаем путь из URL
 	urlPath := parsed.Path
 	if urlPath == "" {
@@ -35,6 +110,22 @@ func (pr *PathResolverImpl) URLToLocalPath(rawURL string) (string, error) {
 	return localPath, nil
 }
 
+// dataURLToLocalPath строит имя файла для data: URL по хэшу содержимого
+func (pr *PathResolverImpl) dataURLToLocalPath(rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	name := hex.EncodeToString(sum[:8])
+
+	// Расширение определяем по типу из заголовка data: URL
+	header, _, _ := strings.Cut(rawURL[len("data:"):], ",")
+	mediaType, _, _ := strings.Cut(header, ";")
+	ext := ".bin"
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		ext = exts[0]
+	}
+
+	return filepath.Join(pr.baseDir, dataDir, name+ext)
+}
+
 func (pr *PathResolverImpl) buildLocalPath(basePath, urlPath string) string {
 	// Если путь заканчивается на /, это директория - создаем index.html
 	if strings.HasSuffix(urlPath, "/") {
@@ -0,0 +1,33 @@
+package path_resolver
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUseFilenameNumbersCollisions(t *testing.T) {
+	resolver := New("out")
+	dir := filepath.Join("out", "example.com")
+
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{url: "http://example.com/download.php?id=1", want: "report.pdf"},
+		{url: "http://example.com/download.php?id=2", want: "report.1.pdf"},
+		{url: "http://example.com/download.php?id=3", want: "report.2.pdf"},
+		// Повторный ответ для того же URL получает прежний путь
+		{url: "http://example.com/download.php?id=1#top", want: "report.pdf"},
+	} {
+		got, err := resolver.UseFilename(tc.url, "report.pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(dir, tc.want); got != want {
+			t.Errorf("UseFilename(%s) = %s, want %s", tc.url, got, want)
+		}
+		if mapped, _ := resolver.URLToLocalPath(tc.url); mapped != got {
+			t.Errorf("URLToLocalPath(%s) = %s, want %s", tc.url, mapped, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package skip_log
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SkipLogImpl пишет пропущенные URL в лог и, если задан путь, в файл
+// строками "URL<TAB>причина"
+type SkipLogImpl struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// New открывает журнал пропусков. Пустой путь означает запись только в лог
+func New(path string) (*SkipLogImpl, error) {
+	if path == "" {
+		return &SkipLogImpl{}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create skip log directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create skip log: %w", err)
+	}
+
+	return &SkipLogImpl{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// Record записывает пропущенный URL и причину пропуска
+func (l *SkipLogImpl) Record(url, reason string) {
+	log.Printf("Skipped %s: %s", url, reason)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer != nil {
+		fmt.Fprintf(l.writer, "%s\t%s\n", url, reason)
+	}
+}
+
+// Close сбрасывает записи на диск и закрывает файл
+func (l *SkipLogImpl) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	if err := l.writer.Flush(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("write skip log: %w", err)
+	}
+	err := l.file.Close()
+	l.file, l.writer = nil, nil
+	return err
+}
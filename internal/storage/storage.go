@@ -1,8 +1,11 @@
 package storage
 
+import "wget-go/internal/domain"
+
 // FileManager управляет файловой системой
 type FileManager interface {
 	Save(filePath string, content []byte) error
+	Load(filePath string) ([]byte, error)
 	Exists(filePath string) bool
 }
 
@@ -10,11 +13,23 @@ type FileManager interface {
 type LinkRewriter interface {
 	RewriteHTML(htmlContent []byte, baseURL string) ([]byte, error)
 	RewriteCSS(cssContent []byte, baseURL string) ([]byte, error)
+	RewriteRenamed(originalHTML []byte, pageURL, pagePath string, renames map[string]string) []byte
+}
+
+// SkipLog записывает URL, пропущенные по правилам обхода, и причину пропуска
+type SkipLog interface {
+	Record(url, reason string)
+}
+
+// Checkpoint сохраняет состояние обхода для продолжения после прерывания
+type Checkpoint interface {
+	Save(state *domain.CrawlState) error
 }
 
 // PathResolver преобразует URL в локальные пути
 type PathResolver interface {
 	URLToLocalPath(url string) (string, error)
+	UseFilename(url, filename string) (string, error)
 	ResolveAbsoluteURL(baseURL, relativeURL string) (string, error)
 	IsSameDomain(url1, url2 string) bool
 }
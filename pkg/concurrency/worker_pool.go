@@ -70,6 +70,16 @@ func (wp *WorkerPool) Submit(task interface{}) {
 	wp.taskQueue <- task
 }
 
+// SubmitContext добавляет задачу в очередь, прерывая ожидание при отмене контекста
+func (wp *WorkerPool) SubmitContext(ctx context.Context, task interface{}) bool {
+	select {
+	case wp.taskQueue <- task:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Close закрывает пул воркеров
 func (wp *WorkerPool) Close() {
 	close(wp.taskQueue)
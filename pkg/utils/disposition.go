@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFilenameLength максимальная длина имени файла в байтах
+const maxFilenameLength = 255
+
+// filenameParamRegex запасной разбор filename= для заголовков, которые
+// отвергает mime.ParseMediaType (например, имя без кавычек с пробелами)
+var filenameParamRegex = regexp.MustCompile(`(?i)filename\s*=\s*(?:"((?:[^"\\]|\\.)*)"|([^;]+))`)
+
+// FilenameFromDisposition извлекает имя файла из Content-Disposition (RFC 6266).
+// Параметр filename* в кодировке RFC 5987 имеет приоритет над filename.
+// Возвращает уже очищенное имя или false, если подходящего имени нет
+func FilenameFromDisposition(header string) (string, bool) {
+	if header == "" {
+		return "", false
+	}
+
+	var filename string
+	if _, params, err := mime.ParseMediaType(header); err == nil {
+		// ParseMediaType декодирует filename* и кладет результат в "filename"
+		filename = params["filename"]
+	} else if match := filenameParamRegex.FindStringSubmatch(header); match != nil {
+		filename = match[1]
+		if filename == "" {
+			filename = strings.TrimSpace(match[2])
+		}
+		filename = strings.ReplaceAll(filename, `\"`, `"`)
+	}
+
+	filename = SanitizeFilename(filename)
+	return filename, filename != ""
+}
+
+// SanitizeFilename делает имя безопасным для сохранения: отбрасывает
+// каталоги, управляющие символы и запрещенные в файловых системах знаки
+func SanitizeFilename(name string) string {
+	// Оставляем только последний компонент пути, защищаясь от обхода каталогов
+	if idx := strings.LastIndexAny(name, `/\`); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return -1
+		case strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		default:
+			return r
+		}
+	}, name)
+
+	// Ведущие точки дают скрытые файлы, замыкающие - проблемы в Windows
+	name = strings.Trim(name, ". ")
+
+	// Укорачиваем основу имени, сохраняя расширение
+	if len(name) > maxFilenameLength {
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for len(stem)+len(ext) > maxFilenameLength && stem != "" {
+			_, size := utf8.DecodeLastRuneInString(stem)
+			stem = stem[:len(stem)-size]
+		}
+		name = stem + ext
+		if len(name) > maxFilenameLength {
+			name = name[:maxFilenameLength]
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestFilenameFromDisposition(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: `attachment; filename="report.pdf"`, want: "report.pdf", ok: true},
+		{header: `attachment; filename*=UTF-8''%D0%BE%D1%82%D1%87%D0%B5%D1%82.pdf`, want: "отчет.pdf", ok: true},
+		{header: `attachment; filename="fallback.pdf"; filename*=UTF-8''real.pdf`, want: "real.pdf", ok: true},
+		// Имя декодируется один раз: литеральные %41 и % остаются как есть
+		{header: `attachment; filename*=UTF-8''%2541.pdf`, want: "%41.pdf", ok: true},
+		{header: `attachment; filename="report%41.pdf"`, want: "report%41.pdf", ok: true},
+		{header: `attachment; filename="100%.pdf"`, want: "100%.pdf", ok: true},
+		{header: `attachment; filename=my report.pdf`, want: "my report.pdf", ok: true},
+		{header: `attachment; filename="../../etc/passwd"`, want: "passwd", ok: true},
+		{header: `attachment; filename="..."`, ok: false},
+		{header: `inline`, ok: false},
+		{header: ``, ok: false},
+	} {
+		got, ok := FilenameFromDisposition(tc.header)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("FilenameFromDisposition(%q) = %q, %v, want %q, %v", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+}
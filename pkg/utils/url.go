@@ -1,6 +1,9 @@
 package utils
 
-import "net/url"
+import (
+	"net/url"
+	"strings"
+)
 
 // NormalizeURL нормализует URL, убирая фрагменты и нормализуя путь
 func NormalizeURL(rawUrl string) (string, error) {
@@ -27,3 +30,8 @@ func IsSameDomain(url1, url2 string) bool {
 
 	return u1.Host == u2.Host
 }
+
+// IsDataURL проверяет, является ли URL встроенным ресурсом data:
+func IsDataURL(rawURL string) bool {
+	return len(rawURL) >= 5 && strings.EqualFold(rawURL[:5], "data:")
+}